@@ -0,0 +1,501 @@
+// cmd/installer/backups.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// maxBackups is how many config backups are kept; the oldest are pruned as
+// new ones are written.
+const maxBackups = 50
+
+// backupRun identifies this installer process in backup metadata. command is
+// the subcommand, or "tui" for the interactive installer.
+var backupRun = struct{ id, command string }{
+	id:      time.Now().Format("20060102-150405") + "-" + strconv.Itoa(os.Getpid()),
+	command: "tui",
+}
+
+// backupMeta describes one config backup. The copy itself sits next to it as
+// <ID>.json.
+type backupMeta struct {
+	ID        string    `json:"id"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"createdAt"`
+	Run       string    `json:"run,omitempty"`
+	Command   string    `json:"command,omitempty"`
+	Task      string    `json:"task,omitempty"`
+	Hash      string    `json:"hash"`
+	Size      int       `json:"size"`
+}
+
+func getBackupsDir() (string, error) {
+	stateDir, err := getStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "backups"), nil
+}
+
+// backupDataPath is where the copy described by b is stored.
+func backupDataPath(dir string, b backupMeta) string {
+	return filepath.Join(dir, b.ID+".json")
+}
+
+// currentTaskName names the running task for backup metadata, or "" outside
+// the task list.
+func (m *model) currentTaskName() string {
+	if m.currentTaskIndex < len(m.tasks) {
+		return m.tasks[m.currentTaskIndex].name
+	}
+	return ""
+}
+
+// backupConfigToDisk copies path into the backups directory, recording which
+// run and task made the copy. An unchanged file isn't backed up twice.
+// Failures are intentionally non-fatal to avoid blocking installation.
+func backupConfigToDisk(path, task string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if _, err := importLegacyBackups(path); err != nil {
+		return err
+	}
+	_, err = writeBackup(backupMeta{
+		Source:    path,
+		CreatedAt: time.Now(),
+		Run:       backupRun.id,
+		Command:   backupRun.command,
+		Task:      task,
+	}, data)
+	return err
+}
+
+// writeBackup stores data as a backup described by meta, filling in its ID,
+// hash and size, then rotates old backups. It returns false when the newest
+// backup of the same file already holds data.
+func writeBackup(meta backupMeta, data []byte) (bool, error) {
+	backups, err := listBackups()
+	if err != nil {
+		return false, err
+	}
+	meta.Hash = hashBytes(data)
+	meta.Size = len(data)
+	for _, b := range backups {
+		if b.Source == meta.Source {
+			if b.Hash == meta.Hash {
+				return false, nil
+			}
+			break
+		}
+	}
+
+	dir, err := getBackupsDir()
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false, fmt.Errorf("failed to create backups directory: %w", err)
+	}
+
+	base := strings.Replace(meta.CreatedAt.Format("20060102-150405.000"), ".", "-", 1)
+	meta.ID = base
+	for n := 1; pathExists(backupDataPath(dir, meta)); n++ {
+		meta.ID = fmt.Sprintf("%s-%d", base, n)
+	}
+
+	if err := os.WriteFile(backupDataPath(dir, meta), data, 0644); err != nil {
+		return false, err
+	}
+	metaData, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, meta.ID+".meta.json"), metaData, 0644); err != nil {
+		return false, err
+	}
+
+	_, err = pruneBackups(maxBackups, 0)
+	return true, err
+}
+
+// listBackups returns the recorded backups, newest first.
+func listBackups() ([]backupMeta, error) {
+	dir, err := getBackupsDir()
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*.meta.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backupMeta
+	for _, path := range matches {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var b backupMeta
+		if err := json.Unmarshal(data, &b); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		backups = append(backups, b)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].CreatedAt.Equal(backups[j].CreatedAt) {
+			return backups[i].CreatedAt.After(backups[j].CreatedAt)
+		}
+		return backups[i].ID > backups[j].ID
+	})
+	return backups, nil
+}
+
+// findBackup looks a backup up by ID, unique ID prefix, or "latest".
+func findBackup(id string) (backupMeta, error) {
+	backups, err := listBackups()
+	if err != nil {
+		return backupMeta{}, err
+	}
+	if len(backups) == 0 {
+		return backupMeta{}, fmt.Errorf("no backups recorded")
+	}
+	if id == "latest" {
+		return backups[0], nil
+	}
+
+	var matches []backupMeta
+	for _, b := range backups {
+		if b.ID == id {
+			return b, nil
+		}
+		if strings.HasPrefix(b.ID, id) {
+			matches = append(matches, b)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return backupMeta{}, fmt.Errorf("no backup %q (see `installer backups list`)", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return backupMeta{}, fmt.Errorf("%q matches %d backups; give more of the ID", id, len(matches))
+	}
+}
+
+// pruneBackups removes backups beyond the keep newest (0 keeps all) and
+// those older than olderThan (0 keeps all), returning what it removed.
+func pruneBackups(keep int, olderThan time.Duration) ([]backupMeta, error) {
+	backups, err := listBackups()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := getBackupsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []backupMeta
+	for i, b := range backups {
+		if (keep <= 0 || i < keep) && (olderThan <= 0 || time.Since(b.CreatedAt) <= olderThan) {
+			continue
+		}
+		for _, path := range []string{backupDataPath(dir, b), filepath.Join(dir, b.ID+".meta.json")} {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return removed, fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+		}
+		removed = append(removed, b)
+	}
+	return removed, nil
+}
+
+// legacyBackups lists the opencode.json.bak.<timestamp> files earlier
+// versions left next to the config.
+func legacyBackups(configPath string) []string {
+	matches, _ := filepath.Glob(configPath + ".bak.*")
+	return matches
+}
+
+// importLegacyBackups moves legacy backups of configPath into the backups
+// directory and returns how many it moved.
+func importLegacyBackups(configPath string) (int, error) {
+	paths := legacyBackups(configPath)
+	sort.Strings(paths)
+
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return i, err
+		}
+		created, err := time.ParseInLocation("20060102-150405", strings.TrimPrefix(path, configPath+".bak."), time.Local)
+		if err != nil {
+			if info, statErr := os.Stat(path); statErr == nil {
+				created = info.ModTime()
+			} else {
+				created = time.Now()
+			}
+		}
+		if _, err := writeBackup(backupMeta{Source: configPath, CreatedAt: created, Task: "legacy"}, data); err != nil {
+			return i, err
+		}
+		if err := os.Remove(path); err != nil {
+			return i, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	return len(paths), nil
+}
+
+// restoreConfigBackup replaces b's source file with the backup. The current
+// file is backed up first, and the new one is written to a temporary file and
+// renamed into place so a failure never leaves a partial config.
+func restoreConfigBackup(b backupMeta) error {
+	dir, err := getBackupsDir()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(backupDataPath(dir, b))
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("backup %s is not valid JSON", b.ID)
+	}
+
+	if err := backupConfigToDisk(b.Source, "restore "+b.ID); err != nil {
+		return fmt.Errorf("failed to back up current config: %w", err)
+	}
+	return atomicWriteFile(b.Source, data)
+}
+
+// atomicWriteFile writes data to path through a temporary file in the same
+// directory. A symlinked path is followed so the link itself survives.
+func atomicWriteFile(path string, data []byte) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// diffBackup diffs backup b against the current contents of its source, with
+// context lines around each change.
+func diffBackup(b backupMeta) ([]string, error) {
+	dir, err := getBackupsDir()
+	if err != nil {
+		return nil, err
+	}
+	old, err := os.ReadFile(backupDataPath(dir, b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	current, err := os.ReadFile(b.Source)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return compactDiff(lineDiff(splitLines(old), splitLines(current)), 3), nil
+}
+
+func splitLines(data []byte) []string {
+	s := strings.TrimRight(string(data), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// compactDiff trims lineDiff output to the changed lines and context lines of
+// unchanged ones around them. It returns nil when nothing changed.
+func compactDiff(lines []string, context int) []string {
+	keep := make([]bool, len(lines))
+	changed := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, "  ") {
+			continue
+		}
+		changed = true
+		for j := max(0, i-context); j <= min(len(lines)-1, i+context); j++ {
+			keep[j] = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	var out []string
+	skipped := 0
+	for i, line := range lines {
+		if !keep[i] {
+			skipped++
+			continue
+		}
+		if skipped > 0 {
+			out = append(out, fmt.Sprintf("@@ %d unchanged lines @@", skipped))
+			skipped = 0
+		}
+		out = append(out, line)
+	}
+	if skipped > 0 {
+		out = append(out, fmt.Sprintf("@@ %d unchanged lines @@", skipped))
+	}
+	return out
+}
+
+// parseAge parses a duration, also accepting whole days such as "30d".
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 72h or 30d)", s)
+	}
+	return d, nil
+}
+
+func cmdBackups(args []string) error {
+	action := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+
+	fs, configPath := newFlagSet("backups " + action)
+	keep := fs.Int("keep", 0, "prune: keep only the N newest backups")
+	olderThan := fs.String("older-than", "", "prune: remove backups older than this (e.g. 72h, 30d)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if n, err := importLegacyBackups(*configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to import old backups: %v\n", err)
+	} else if n > 0 {
+		fmt.Printf("Moved %d backup(s) from next to %s into the backups directory\n", n, *configPath)
+	}
+
+	switch action {
+	case "list":
+		return listBackupsCmd()
+	case "diff":
+		id := "latest"
+		if fs.NArg() > 0 {
+			id = fs.Arg(0)
+		}
+		b, err := findBackup(id)
+		if err != nil {
+			return err
+		}
+		lines, err := diffBackup(b)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("--- backup %s\n+++ %s (current)\n", b.ID, b.Source)
+		if len(lines) == 0 {
+			fmt.Println("No differences")
+		}
+		for _, line := range lines {
+			fmt.Println(line)
+		}
+		return nil
+	case "restore":
+		if fs.NArg() == 0 {
+			return fmt.Errorf("usage: installer backups restore <id|latest>")
+		}
+		b, err := findBackup(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		if err := restoreConfigBackup(b); err != nil {
+			return err
+		}
+		fmt.Printf("Restored %s from backup %s\n", b.Source, b.ID)
+		return nil
+	case "prune":
+		if *keep <= 0 && *olderThan == "" {
+			return fmt.Errorf("usage: installer backups prune [--keep N] [--older-than AGE]")
+		}
+		var age time.Duration
+		if *olderThan != "" {
+			var err error
+			if age, err = parseAge(*olderThan); err != nil {
+				return err
+			}
+		}
+		removed, err := pruneBackups(*keep, age)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d backup(s)\n", len(removed))
+		return nil
+	default:
+		return fmt.Errorf("unknown backups action %q (list, diff, restore or prune)", action)
+	}
+}
+
+func listBackupsCmd() error {
+	backups, err := listBackups()
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		fmt.Println("No backups recorded")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCREATED\tCOMMAND\tTASK\tSIZE\tFILE")
+	for _, b := range backups {
+		command, task := b.Command, b.Task
+		if command == "" {
+			command = "-"
+		}
+		if task == "" {
+			task = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d B\t%s\n", b.ID, b.CreatedAt.Local().Format("2006-01-02 15:04:05"), command, task, b.Size, b.Source)
+	}
+	return w.Flush()
+}
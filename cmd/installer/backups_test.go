@@ -0,0 +1,163 @@
+// cmd/installer/backups_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBackupConfigToDiskRecordsAndDedupes(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "opencode.json")
+
+	if err := os.WriteFile(configPath, []byte(`{"model":"a"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := backupConfigToDisk(configPath, "Update config"); err != nil {
+		t.Fatal(err)
+	}
+	if err := backupConfigToDisk(configPath, "Set default model"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte(`{"model":"b"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := backupConfigToDisk(configPath, "Set default model"); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := listBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("got %d backups, want 2 (unchanged file backed up once)", len(backups))
+	}
+	if backups[0].Task != "Set default model" || backups[1].Task != "Update config" {
+		t.Errorf("tasks = %q, %q; want newest first", backups[0].Task, backups[1].Task)
+	}
+	if backups[0].Run != backupRun.id || backups[0].Source != configPath {
+		t.Errorf("metadata = %+v", backups[0])
+	}
+}
+
+func TestImportLegacyBackups(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "opencode.json")
+	legacy := configPath + ".bak.20250102-030405"
+	if err := os.WriteFile(legacy, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := importLegacyBackups(configPath)
+	if err != nil || n != 1 {
+		t.Fatalf("importLegacyBackups = %d, %v", n, err)
+	}
+	if pathExists(legacy) {
+		t.Error("legacy backup should have been moved")
+	}
+	b, err := findBackup("latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2025, 1, 2, 3, 4, 5, 0, time.Local)
+	if !b.CreatedAt.Equal(want) || b.Task != "legacy" {
+		t.Errorf("imported backup = %+v", b)
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	now := time.Now()
+	for i, age := range []time.Duration{time.Hour, 48 * time.Hour, 96 * time.Hour} {
+		meta := backupMeta{Source: "/cfg", CreatedAt: now.Add(-age)}
+		if _, err := writeBackup(meta, []byte{byte('a' + i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := pruneBackups(0, 72*time.Hour)
+	if err != nil || len(removed) != 1 {
+		t.Fatalf("prune by age removed %d, %v", len(removed), err)
+	}
+	removed, err = pruneBackups(1, 0)
+	if err != nil || len(removed) != 1 {
+		t.Fatalf("prune by count removed %d, %v", len(removed), err)
+	}
+	backups, _ := listBackups()
+	if len(backups) != 1 || !backups[0].CreatedAt.Equal(now.Add(-time.Hour)) {
+		t.Errorf("remaining = %+v", backups)
+	}
+}
+
+func TestRestoreConfigBackupFollowsSymlink(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	root := t.TempDir()
+	target := filepath.Join(root, "dotfiles", "opencode.json")
+	configPath := filepath.Join(root, "opencode.json")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte(`{"model":"old"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, configPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := backupConfigToDisk(configPath, "Update config"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte(`{"model":"new"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := findBackup("latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restoreConfigBackup(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if mode := detectLinkMode(configPath); mode != linkModeSymlink {
+		t.Errorf("config link mode = %q, want the symlink kept", mode)
+	}
+	data, _ := os.ReadFile(target)
+	if string(data) != `{"model":"old"}` {
+		t.Errorf("restored = %s", data)
+	}
+	if info, _ := os.Stat(target); info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600 kept", info.Mode().Perm())
+	}
+	backups, _ := listBackups()
+	if len(backups) != 2 || backups[0].Task != "restore "+b.ID {
+		t.Errorf("expected the replaced config to be backed up, got %+v", backups)
+	}
+}
+
+func TestCompactDiff(t *testing.T) {
+	a := []string{"{", "1", "2", "3", "4", "5", "6", "}"}
+	b := []string{"{", "1", "2", "3", "4", "5", "six", "}"}
+	got := compactDiff(lineDiff(a, b), 1)
+	want := []string{"@@ 5 unchanged lines @@", "  5", "- 6", "+ six", "  }"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("compactDiff = %q, want %q", got, want)
+	}
+	if compactDiff(lineDiff(a, a), 3) != nil {
+		t.Error("identical input should give no diff")
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	for in, want := range map[string]time.Duration{"30d": 30 * 24 * time.Hour, "72h": 72 * time.Hour} {
+		if got, err := parseAge(in); err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v", in, got, err)
+		}
+	}
+	if _, err := parseAge("soon"); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,397 @@
+// cmd/installer/changes.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+// opencode.json settings install owns, as manifestChange keys.
+const (
+	configKeyProvider   = "provider." + opencodeconfig.ProviderID
+	configKeyModel      = "model"
+	configKeySmallModel = "small_model"
+	configKeyPlugin     = "plugin"
+)
+
+// configValue returns the JSON of a tracked setting, or nil when unset.
+func configValue(config *opencodeconfig.Config, key string) json.RawMessage {
+	var v interface{}
+	switch key {
+	case configKeyProvider:
+		if p := config.CursorACP(); p != nil {
+			v = p
+		}
+	case configKeyModel:
+		if config.Model != "" {
+			v = config.Model
+		}
+	case configKeySmallModel:
+		if config.SmallModel != "" {
+			v = config.SmallModel
+		}
+	}
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// setConfigValue puts a tracked setting back to raw; nil unsets it.
+func setConfigValue(config *opencodeconfig.Config, key string, raw json.RawMessage) error {
+	switch key {
+	case configKeyProvider:
+		if raw == nil {
+			config.RemoveProvider(opencodeconfig.ProviderID)
+			return nil
+		}
+		var p opencodeconfig.Provider
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return err
+		}
+		if config.Provider == nil {
+			config.Provider = make(map[string]*opencodeconfig.Provider)
+		}
+		config.Provider[opencodeconfig.ProviderID] = &p
+	case configKeyModel:
+		config.Model = priorString(raw)
+	case configKeySmallModel:
+		config.SmallModel = priorString(raw)
+	default:
+		return fmt.Errorf("unknown config key %q", key)
+	}
+	return nil
+}
+
+// configHash hashes a setting's value for change detection. The provider's
+// model list is left out: sync-models rewrites it as a matter of course.
+func configHash(key string, raw json.RawMessage) string {
+	if raw == nil {
+		return ""
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return hashBytes(raw)
+	}
+	if obj, ok := v.(map[string]interface{}); ok && key == configKeyProvider {
+		delete(obj, "models")
+	}
+	// Re-marshalling sorts object keys, so formatting and order don't count.
+	canonical, _ := json.Marshal(v)
+	return hashBytes(canonical)
+}
+
+// configChanges lists what changed in the tracked settings between before
+// and after.
+func configChanges(path string, before, after *opencodeconfig.Config) []manifestChange {
+	var changes []manifestChange
+	for _, key := range []string{configKeyProvider, configKeyModel, configKeySmallModel} {
+		prior, written := configValue(before, key), configValue(after, key)
+		if configHash(key, prior) == configHash(key, written) {
+			continue
+		}
+		changes = append(changes, manifestChange{
+			Kind:    changeConfig,
+			Path:    path,
+			Key:     key,
+			Hash:    configHash(key, written),
+			Existed: prior != nil,
+			Prior:   prior,
+		})
+	}
+
+	// Each cursor-acp entry added is paired with one it replaced, if any.
+	removed := cursorACPSpecsNotIn(before, after)
+	for i, spec := range cursorACPSpecsNotIn(after, before) {
+		c := manifestChange{Kind: changeConfig, Path: path, Key: configKeyPlugin, Value: spec}
+		if i < len(removed) {
+			c.Prior, _ = json.Marshal(removed[i])
+			c.Existed = true
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// cursorACPSpecsNotIn returns a's cursor-acp plugin specs missing from b.
+func cursorACPSpecsNotIn(a, b *opencodeconfig.Config) []string {
+	var specs []string
+	for _, e := range a.Plugin {
+		if !e.IsCursorACP() || !e.IsString() {
+			continue
+		}
+		if !b.HasPlugin(func(o opencodeconfig.PluginEntry) bool { return o.Spec == e.Spec }) {
+			specs = append(specs, e.Spec)
+		}
+	}
+	return specs
+}
+
+// saveTrackedConfig saves config and records how it differs from before, a
+// Clone taken when it was loaded.
+func saveTrackedConfig(path string, before, config *opencodeconfig.Config) error {
+	if err := config.Save(path); err != nil {
+		return err
+	}
+	return recordChanges(configChanges(path, before, config)...)
+}
+
+// revertReport is the outcome of reverting manifest changes.
+type revertReport struct {
+	reverted []string
+	kept     []string // existed before install
+	modified []string // changed since install, left in place
+}
+
+// revertChanges undoes the manifest's changes that selected picks (all when
+// nil), newest first. Items changed since install are reported and left
+// alone.
+func revertChanges(m *model, mf *installManifest, selected func(manifestChange) bool) (revertReport, error) {
+	var report revertReport
+
+	var pending []manifestChange
+	for i := len(mf.Changes) - 1; i >= 0; i-- {
+		c := mf.Changes[i]
+		if selected != nil && !selected(c) {
+			continue
+		}
+		var err error
+		switch c.Kind {
+		case changeConfig:
+			pending = append(pending, c)
+		case changeFile:
+			err = revertFile(c, &report)
+		case changeDir:
+			err = revertDir(c, &report)
+		case changePackage:
+			err = revertPackage(m, c, &report)
+		}
+		if err != nil {
+			return report, err
+		}
+	}
+
+	byPath := make(map[string][]manifestChange)
+	var paths []string
+	for _, c := range pending {
+		if _, ok := byPath[c.Path]; !ok {
+			paths = append(paths, c.Path)
+		}
+		byPath[c.Path] = append(byPath[c.Path], c)
+	}
+	for _, path := range paths {
+		if err := revertConfig(path, byPath[path], &report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+func revertFile(c manifestChange, report *revertReport) error {
+	// A regular file that was there before the install has no recorded
+	// content to restore, so it stays.
+	if c.Existed && c.Prior == nil {
+		report.kept = append(report.kept, c.Path)
+		return nil
+	}
+	if !pathExists(c.Path) {
+		return nil
+	}
+	current, err := pluginFileChange(c.Path, false, "")
+	if err != nil {
+		return err
+	}
+	if current.Value != c.Value || current.Hash != c.Hash {
+		report.modified = append(report.modified, c.Path+" (changed since install)")
+		return nil
+	}
+	if err := os.Remove(c.Path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", c.Path, err)
+	}
+	if prior := priorString(c.Prior); prior != "" {
+		if err := os.Symlink(prior, c.Path); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", c.Path, err)
+		}
+		report.reverted = append(report.reverted, c.Path+" → "+prior+" (restored)")
+		return nil
+	}
+	report.reverted = append(report.reverted, c.Path)
+	return nil
+}
+
+func revertDir(c manifestChange, report *revertReport) error {
+	if c.Existed {
+		report.kept = append(report.kept, c.Path)
+		return nil
+	}
+	if !pathExists(c.Path) {
+		return nil
+	}
+	if err := os.RemoveAll(c.Path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", c.Path, err)
+	}
+	report.reverted = append(report.reverted, c.Path)
+	return nil
+}
+
+func revertPackage(m *model, c manifestChange, report *revertReport) error {
+	if c.Existed {
+		report.kept = append(report.kept, c.Key)
+		return nil
+	}
+	var cmd *exec.Cmd
+	switch c.Via {
+	case "bun":
+		if !pathExists(c.Path) {
+			return nil
+		}
+		cmd = exec.Command("bun", "remove", c.Key)
+		cmd.Dir = c.Path
+	case "npm -g":
+		cmd = exec.Command("npm", "uninstall", "-g", c.Key)
+	default:
+		return fmt.Errorf("unknown package manager %q for %s", c.Via, c.Key)
+	}
+	if err := runCommand(c.Via+" remove "+c.Key, cmd, m.logFile); err != nil {
+		return err
+	}
+	report.reverted = append(report.reverted, c.Key)
+	return nil
+}
+
+// revertConfig restores the tracked settings in one opencode.json. changes
+// are newest first.
+func revertConfig(path string, changes []manifestChange, report *revertReport) error {
+	config, err := opencodeconfig.Load(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	changed := false
+	for _, c := range changes {
+		label := path + ": " + c.Key
+		if c.Key == configKeyPlugin {
+			label = fmt.Sprintf("%s: plugin %q", path, c.Value)
+			index := -1
+			for i, e := range config.Plugin {
+				if e.IsString() && e.Spec == c.Value {
+					index = i
+					break
+				}
+			}
+			if index < 0 {
+				report.modified = append(report.modified, label+" (no longer present)")
+				continue
+			}
+			if prior := priorString(c.Prior); prior != "" {
+				config.Plugin[index] = opencodeconfig.Plugin(prior)
+				label += " → " + prior
+			} else {
+				config.Plugin = append(config.Plugin[:index], config.Plugin[index+1:]...)
+			}
+			report.reverted = append(report.reverted, label)
+			changed = true
+			continue
+		}
+
+		if configHash(c.Key, configValue(config, c.Key)) != c.Hash {
+			report.modified = append(report.modified, label+" (changed since install)")
+			continue
+		}
+		if err := setConfigValue(config, c.Key, c.Prior); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", label, err)
+		}
+		if c.Existed {
+			label += " (restored)"
+		}
+		report.reverted = append(report.reverted, label)
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	_ = backupConfigToDisk(path, "revert")
+	return config.Save(path)
+}
+
+// revertInstallTask returns the uninstall task for when an install manifest
+// exists. It reverts the recorded changes selected picks, reports what it
+// left alone, and keeps the rest in the manifest.
+func revertInstallTask(selected func(manifestChange) bool) func(*model) error {
+	return func(m *model) error {
+		return revertInstall(m, selected)
+	}
+}
+
+func revertInstall(m *model, selected func(manifestChange) bool) error {
+	mf, err := loadInstallManifest()
+	if err != nil {
+		return fmt.Errorf("failed to read install manifest: %w", err)
+	}
+	if err := createBackup(m, m.configPath); err != nil {
+		return fmt.Errorf("failed to backup config: %w", err)
+	}
+
+	report, err := revertChanges(m, mf, selected)
+	if err != nil {
+		return err
+	}
+
+	var remaining []manifestChange
+	for _, c := range mf.Changes {
+		if selected != nil && !selected(c) {
+			remaining = append(remaining, c)
+		}
+	}
+	if len(remaining) == 0 {
+		err = removeInstallManifest()
+	} else {
+		mf.Changes = remaining
+		err = mf.save()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update install manifest: %w", err)
+	}
+
+	if len(report.kept) == 0 && len(report.modified) == 0 {
+		return nil
+	}
+	var body []string
+	for _, item := range report.reverted {
+		body = append(body, "✓ "+item)
+	}
+	for _, item := range report.kept {
+		body = append(body, "= "+item+" (existed before install, kept)")
+	}
+	for _, item := range report.modified {
+		body = append(body, "! "+item+", left in place")
+	}
+	m.prompt = &taskPrompt{
+		title:   fmt.Sprintf("Reverted %d changes; %d left in place", len(report.reverted), len(report.kept)+len(report.modified)),
+		body:    body,
+		options: []promptOption{{key: "enter", label: "Continue"}},
+	}
+	return nil
+}
+
+func validateJSONAfterRevert(m *model) error {
+	if !pathExists(m.configPath) {
+		return nil
+	}
+	if err := validateJSON(m.configPath); err != nil {
+		return fmt.Errorf("config validation failed: %w", err)
+	}
+	return nil
+}
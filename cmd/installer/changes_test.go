@@ -0,0 +1,200 @@
+// cmd/installer/changes_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+func writeTestConfig(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func loadTestConfig(t *testing.T, path string) *opencodeconfig.Config {
+	t.Helper()
+	config, err := opencodeconfig.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestRevertConfigChanges(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "opencode.json")
+	writeTestConfig(t, path, `{"model": "anthropic/claude", "plugin": ["other-plugin"]}`)
+
+	// Install: provider, plugin entry and default model.
+	config := loadTestConfig(t, path)
+	before := config.Clone()
+	provider, err := config.EnsureCursorACP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.SetModels(map[string]string{"auto": "Auto"})
+	config.AddPlugin(opencodeconfig.NPMPackage + "@latest")
+	config.Model = "cursor-acp/auto"
+	if err := saveTrackedConfig(path, before, config); err != nil {
+		t.Fatal(err)
+	}
+
+	// Upgrade retags the entry; a model sync rewrites the model list.
+	config = loadTestConfig(t, path)
+	before = config.Clone()
+	config.Plugin[1] = opencodeconfig.Plugin(opencodeconfig.NPMPackage + "@2.3.9")
+	config.CursorACP().SetModels(map[string]string{"auto": "Auto", "gpt-5": "GPT-5"})
+	if err := saveTrackedConfig(path, before, config); err != nil {
+		t.Fatal(err)
+	}
+
+	// The user adds a plugin of their own.
+	config = loadTestConfig(t, path)
+	config.AddPlugin("user-plugin")
+	if err := config.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	mf, err := loadInstallManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mf.Changes) != 3 {
+		t.Fatalf("manifest has %d changes, want 3 (provider, plugin, model): %+v", len(mf.Changes), mf.Changes)
+	}
+
+	report, err := revertChanges(&model{}, mf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.modified) != 0 {
+		t.Errorf("modified = %v, want none", report.modified)
+	}
+
+	config = loadTestConfig(t, path)
+	if config.CursorACP() != nil {
+		t.Error("cursor-acp provider not removed")
+	}
+	if config.Model != "anthropic/claude" {
+		t.Errorf("model = %q, want the prior value restored", config.Model)
+	}
+	var specs []string
+	for _, e := range config.Plugin {
+		specs = append(specs, e.Spec)
+	}
+	if got := strings.Join(specs, ","); got != "other-plugin,user-plugin" {
+		t.Errorf("plugins = %s", got)
+	}
+}
+
+func TestRevertLeavesModifiedSettings(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "opencode.json")
+	writeTestConfig(t, path, `{}`)
+
+	config := loadTestConfig(t, path)
+	before := config.Clone()
+	if _, err := config.EnsureCursorACP(); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveTrackedConfig(path, before, config); err != nil {
+		t.Fatal(err)
+	}
+
+	config = loadTestConfig(t, path)
+	config.CursorACP().Options.BaseURL = "http://127.0.0.1:4000/v1"
+	if err := config.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	mf, err := loadInstallManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := revertChanges(&model{}, mf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.modified) != 1 || !strings.Contains(report.modified[0], configKeyProvider) {
+		t.Errorf("modified = %v, want the provider", report.modified)
+	}
+	if loadTestConfig(t, path).CursorACP() == nil {
+		t.Error("edited provider was removed")
+	}
+}
+
+func TestRevertPluginFileRestoresPriorLink(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	root := t.TempDir()
+	oldEntry := writePluginEntry(t, filepath.Join(root, "old"))
+	newEntry := writePluginEntry(t, filepath.Join(root, "new"))
+	path := pluginFilePath(filepath.Join(root, "plugin"))
+
+	if err := linkPluginFile(oldEntry, path, linkModeSymlink); err != nil {
+		t.Fatal(err)
+	}
+	if err := linkPluginFile(newEntry, path, linkModeSymlink); err != nil {
+		t.Fatal(err)
+	}
+	change, err := pluginFileChange(path, true, oldEntry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recordChanges(change); err != nil {
+		t.Fatal(err)
+	}
+
+	mf, err := loadInstallManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := revertChanges(&model{}, mf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if target, _ := os.Readlink(path); target != oldEntry {
+		t.Errorf("plugin file points at %q, want %q", target, oldEntry)
+	}
+}
+
+func TestRevertPluginFileKeepsPriorCopy(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	root := t.TempDir()
+	entry := writePluginEntry(t, filepath.Join(root, "new"))
+	path := pluginFilePath(filepath.Join(root, "plugin"))
+	writeTestConfig(t, path, "// the user's own copy\n")
+
+	if err := linkPluginFile(entry, path, linkModeSymlink); err != nil {
+		t.Fatal(err)
+	}
+	change, err := pluginFileChange(path, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recordChanges(change); err != nil {
+		t.Fatal(err)
+	}
+
+	mf, err := loadInstallManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := revertChanges(&model{}, mf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pathExists(path) {
+		t.Error("removed a plugin file that existed before the install")
+	}
+	if len(report.kept) != 1 || report.kept[0] != path || len(report.reverted) != 0 {
+		t.Errorf("kept = %q, reverted = %q", report.kept, report.reverted)
+	}
+}
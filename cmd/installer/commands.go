@@ -0,0 +1,177 @@
+// cmd/installer/commands.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+// subcommand is a headless entry point run instead of the TUI, e.g.
+// `installer sync-models`.
+type subcommand struct {
+	summary string
+	run     func(args []string) error
+}
+
+var subcommands map[string]subcommand
+
+func init() {
+	subcommands = map[string]subcommand{
+		"sync-models":      {summary: "Refresh cursor-acp models in opencode.json from cursor-agent", run: cmdSyncModels},
+		"disable":          {summary: "Turn cursor-acp off, stashing its provider and plugin entry", run: cmdDisable},
+		"enable":           {summary: "Restore what disable stashed", run: cmdEnable},
+		"uninstall":        {summary: "Remove the plugin (--level config|plugin|purge, --dry-run to preview)", run: cmdUninstall},
+		"status":           {summary: "Show what is installed and how (--json for scripts)", run: cmdStatus},
+		"duplicates":       {summary: "List every installed copy of the plugin (--remove to clean up unused ones)", run: cmdDuplicates},
+		"doctor":           {summary: "Diagnose the environment and install (--fix to apply fixes, --json for scripts)", run: cmdDoctor},
+		"backups":          {summary: "List, diff, restore or prune opencode.json backups (list|diff ID|restore ID|prune)", run: cmdBackups},
+		"restore-snapshot": {summary: "Put ~/.config/opencode back as a pre-install snapshot left it (--list to list)", run: cmdRestoreSnapshot},
+		"schedule-sync":    {summary: "Install or remove a periodic background sync-models job", run: cmdScheduleSync},
+		"help":             {summary: "Show this help", run: cmdHelp},
+	}
+}
+
+// runSubcommand runs the named subcommand and returns the process exit code.
+func runSubcommand(name string, args []string) int {
+	cmd, ok := subcommands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
+		printUsage(os.Stderr)
+		return 2
+	}
+	backupRun.command = name
+	if err := cmd.run(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: installer [--debug] [--no-rollback] [--probe-models] [--choose-defaults] [--run-tests] [--snapshot[-node-modules]] [--plugin-version latest|next|X.Y.Z] [--pin-installed]")
+	fmt.Fprintln(w, "                 [--from-tarball FILE.tgz | --from-dir DIR | --from-git URL[#REF]] [--copy | --relative-link]")
+	fmt.Fprintln(w, "       installer <command> [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-17s %s\n", name, subcommands[name].summary)
+	}
+}
+
+func cmdHelp(args []string) error {
+	printUsage(os.Stdout)
+	return nil
+}
+
+// newFlagSet returns a flag set for a subcommand with the shared --config flag.
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	_, defaultConfig := detectExistingSetup()
+	configPath := fs.String("config", defaultConfig, "path to opencode.json")
+	return fs, configPath
+}
+
+// newHeadlessModel builds a model for running install tasks outside the TUI.
+// Prompts raised by tasks are ignored.
+func newHeadlessModel(configPath string) model {
+	configDir, _ := getConfigDir()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return model{
+		ctx:         ctx,
+		cancel:      cancel,
+		projectDir:  getProjectDir(),
+		pluginDir:   filepath.Join(configDir, "opencode", "plugin"),
+		configPath:  configPath,
+		backupFiles: make(map[string][]byte),
+		npmTag:      "latest",
+	}
+}
+
+// syncState is the result of the last headless sync-models run, kept in the
+// state dir for status reporting.
+type syncState struct {
+	Time   time.Time `json:"time"`
+	Models int       `json:"models"`
+	Error  string    `json:"error,omitempty"`
+}
+
+func cmdSyncModels(args []string) error {
+	fs, configPath := newFlagSet("sync-models")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	m := newHeadlessModel(*configPath)
+	defer m.cancel()
+
+	state := syncState{Time: time.Now()}
+	err := fetchAndAddModels(&m)
+	if err == nil {
+		if config, err := opencodeconfig.Load(*configPath); err == nil {
+			state.Models = len(config.CursorACP().ModelIDs())
+			for _, issue := range lintModelRefs(config) {
+				fmt.Fprintf(os.Stderr, "Warning: dangling model reference %s\n", issue)
+			}
+		}
+	} else {
+		state.Error = err.Error()
+	}
+
+	if logErr := recordSyncState(state); logErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record sync result: %v\n", logErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Models synced: %d\n", state.Models)
+	fmt.Printf("Config path: %s\n", *configPath)
+	return nil
+}
+
+// recordSyncState appends the result to sync.log and overwrites
+// last-sync.json in the state dir.
+func recordSyncState(state syncState) error {
+	stateDir, err := ensureStateDir()
+	if err != nil {
+		return err
+	}
+
+	line := fmt.Sprintf("%s models=%d", state.Time.Format(time.RFC3339), state.Models)
+	if state.Error != "" {
+		line += " error=" + state.Error
+	}
+	logFile, err := os.OpenFile(filepath.Join(stateDir, "sync.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+	if _, err := logFile.WriteString(line + "\n"); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(stateDir, "last-sync.json"), data, 0644)
+}
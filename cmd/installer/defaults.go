@@ -0,0 +1,151 @@
+// cmd/installer/defaults.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+// chooseDefaultModels is the optional "Choose default models" task. It offers
+// the fetched cursor-acp models for opencode.json "model" and "small_model",
+// preselecting auto, and warns about existing values whose provider is not
+// configured.
+func chooseDefaultModels(m *model) error {
+	config, err := opencodeconfig.Load(m.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	choices := cursorAcpModelRefs(config)
+	if len(choices) == 0 {
+		return fmt.Errorf("no cursor-acp models configured")
+	}
+
+	body := danglingDefaultWarnings(config)
+	m.prompt = defaultModelPrompt("model", "Default model", choices, body)
+	return nil
+}
+
+// defaultModelPrompt builds the picker for one of the default model keys and
+// chains the small_model picker after "model".
+func defaultModelPrompt(key, title string, choices, body []string) *taskPrompt {
+	cursor := 0
+	for i, c := range choices {
+		if c == "cursor-acp/auto" {
+			cursor = i
+			break
+		}
+	}
+
+	next := func(m *model) {
+		if key == "model" {
+			m.prompt = defaultModelPrompt("small_model", "Small model (titles, summaries)", choices, nil)
+		}
+	}
+
+	return &taskPrompt{
+		title:   fmt.Sprintf("%s for opencode.json %q", title, key),
+		body:    body,
+		choices: choices,
+		cursor:  cursor,
+		options: []promptOption{
+			{key: "enter", label: "Set " + key, apply: func(m *model, choice string) error {
+				if err := setConfigDefaultModel(m, key, choice); err != nil {
+					return err
+				}
+				next(m)
+				return nil
+			}},
+			{key: "s", label: "Skip", apply: func(m *model, _ string) error {
+				next(m)
+				return nil
+			}},
+		},
+	}
+}
+
+func setConfigDefaultModel(m *model, key, ref string) error {
+	_ = backupConfigToDisk(m.configPath, m.currentTaskName())
+
+	config, err := opencodeconfig.Load(m.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	before := config.Clone()
+	if key == "model" {
+		config.Model = ref
+	} else {
+		config.SmallModel = ref
+	}
+	if err := saveTrackedConfig(m.configPath, before, config); err != nil {
+		return err
+	}
+
+	if key == "model" {
+		m.defaultModel = ref
+	} else {
+		m.smallModel = ref
+	}
+	return nil
+}
+
+// cursorAcpModelRefs returns "cursor-acp/<id>" for every configured model,
+// auto first and the rest sorted.
+func cursorAcpModelRefs(config *opencodeconfig.Config) []string {
+	ids := config.CursorACP().ModelIDs()
+	sort.SliceStable(ids, func(a, b int) bool {
+		return ids[a] == "auto" && ids[b] != "auto"
+	})
+
+	refs := make([]string, len(ids))
+	for i, id := range ids {
+		refs[i] = opencodeconfig.ProviderID + "/" + id
+	}
+	return refs
+}
+
+// danglingDefaultWarnings reports "model" / "small_model" values whose
+// provider is neither in the config nor logged in through `opencode auth`.
+func danglingDefaultWarnings(config *opencodeconfig.Config) []string {
+	configured := configuredProviders(config)
+
+	var warnings []string
+	for _, d := range []struct{ key, ref string }{{"model", config.Model}, {"small_model", config.SmallModel}} {
+		provider, _, ok := opencodeconfig.SplitModelRef(d.ref)
+		if !ok || configured[provider] {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("⚠ %s is %q but provider %q is not configured", d.key, d.ref, provider))
+	}
+	return warnings
+}
+
+// configuredProviders returns providers declared in opencode.json plus those
+// with credentials in OpenCode's auth.json.
+func configuredProviders(config *opencodeconfig.Config) map[string]bool {
+	configured := make(map[string]bool)
+	for id := range config.Provider {
+		configured[id] = true
+	}
+
+	dataDir, err := getDataDir()
+	if err != nil {
+		return configured
+	}
+	data, err := os.ReadFile(filepath.Join(dataDir, "opencode", "auth.json"))
+	if err != nil {
+		return configured
+	}
+	var auth map[string]interface{}
+	if err := json.Unmarshal(data, &auth); err == nil {
+		for id := range auth {
+			configured[id] = true
+		}
+	}
+	return configured
+}
@@ -0,0 +1,65 @@
+// cmd/installer/defaults_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCursorAcpModelRefs(t *testing.T) {
+	m := newTestModel(t)
+	writeTestConfig(t, m.configPath, `{"provider": {"cursor-acp": {"models": {"sonnet-4.5": {}, "auto": {}, "gpt-5": {}}}}}`)
+
+	got := cursorAcpModelRefs(loadTestConfig(t, m.configPath))
+	want := []string{"cursor-acp/auto", "cursor-acp/gpt-5", "cursor-acp/sonnet-4.5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("refs = %v, want %v", got, want)
+	}
+
+	writeTestConfig(t, m.configPath, `{}`)
+	if got := cursorAcpModelRefs(loadTestConfig(t, m.configPath)); len(got) != 0 {
+		t.Errorf("refs without provider = %v", got)
+	}
+}
+
+func TestDanglingDefaultWarnings(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   []string // substrings, one per warning
+	}{
+		{"configured provider", `{"model": "cursor-acp/auto", "provider": {"cursor-acp": {}}}`, nil},
+		{"unset", `{"provider": {"cursor-acp": {}}}`, nil},
+		{"logged in through opencode auth", `{"model": "anthropic/claude-sonnet-4-5"}`, nil},
+		{"dangling model", `{"model": "openai/gpt-5", "provider": {"cursor-acp": {}}}`, []string{`model is "openai/gpt-5"`}},
+		{"both dangling", `{"model": "openai/gpt-5", "small_model": "groq/llama", "provider": {"cursor-acp": {}}}`, []string{`"openai"`, `small_model is "groq/llama"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t)
+			dataDir := t.TempDir()
+			t.Setenv("XDG_DATA_HOME", dataDir)
+			auth := filepath.Join(dataDir, "opencode", "auth.json")
+			if err := os.MkdirAll(filepath.Dir(auth), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(auth, []byte(`{"anthropic": {"type": "oauth"}}`), 0600); err != nil {
+				t.Fatal(err)
+			}
+			writeTestConfig(t, m.configPath, tt.config)
+
+			got := danglingDefaultWarnings(loadTestConfig(t, m.configPath))
+			if len(got) != len(tt.want) {
+				t.Fatalf("warnings = %q, want %d", got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				if !strings.Contains(got[i], w) {
+					t.Errorf("warning %d = %q, want it to mention %s", i, got[i], w)
+				}
+			}
+		})
+	}
+}
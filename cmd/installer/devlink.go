@@ -0,0 +1,384 @@
+// cmd/installer/devlink.go
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+// devStatus is the state of the watched build.
+type devStatus int
+
+const (
+	devStarting devStatus = iota
+	devOK
+	devFailed
+	devStopped
+)
+
+const (
+	devMaxErrors = 8
+	devMaxOutput = 6
+)
+
+// devSession is the state of dev-link mode: `bun run dev` rebuilding the
+// checkout on change, with the plugin symlink pointed at its output.
+type devSession struct {
+	dir        string
+	entry      string // dir/dist/plugin-entry.js
+	linkPath   string
+	prevTarget string // symlink target before dev mode, "" if there was none
+	prevCopy   []byte // file contents before dev mode, if it was a copy
+	linked     bool
+
+	// configPath's plugin array before dev mode switched it to the local
+	// entry; pluginsChanged is set once it has.
+	configPath     string
+	prevPlugins    []opencodeconfig.PluginEntry
+	pluginsChanged bool
+
+	status    devStatus
+	builds    int
+	lastBuild time.Time
+	errors    []string
+	output    []string
+	warning   string
+	quitting  bool
+
+	cmd    *exec.Cmd
+	events chan devEvent
+}
+
+// devEvent is a line of watcher output, or its exit.
+type devEvent struct {
+	line   string
+	exited bool
+	err    error
+}
+
+type devEventMsg devEvent
+
+var devBundledRegex = regexp.MustCompile(`^Bundled \d+ modules?`)
+
+// startDevMode links the checkout's build output into OpenCode and starts
+// `bun run dev` (bun build --watch).
+func (m model) startDevMode() (tea.Model, tea.Cmd) {
+	linkPath := pluginFilePath(m.pluginDir)
+	prevTarget, _ := os.Readlink(linkPath)
+	var prevCopy []byte
+	if detectLinkMode(linkPath) == linkModeCopy {
+		prevCopy, _ = os.ReadFile(linkPath)
+	}
+
+	dev := &devSession{
+		dir:        m.projectDir,
+		entry:      filepath.Join(m.projectDir, "dist", "plugin-entry.js"),
+		linkPath:   linkPath,
+		prevTarget: prevTarget,
+		prevCopy:   prevCopy,
+		configPath: m.configPath,
+		events:     make(chan devEvent, 64),
+	}
+	if _, err := opencodeconfig.Load(m.configPath); err != nil {
+		dev.warning = fmt.Sprintf("can't read %s (%v), so OpenCode may not load the link", m.configPath, err)
+	}
+
+	cmd := exec.CommandContext(m.ctx, "bun", "run", "dev")
+	cmd.Dir = dev.dir
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error { return killProcessGroup(cmd) }
+	cmd.WaitDelay = time.Second
+	dev.cmd = cmd
+
+	m.dev = dev
+	m.step = stepDev
+	return m, tea.Batch(startDevWatcher(dev), waitDevEvent(dev.events))
+}
+
+// startDevWatcher starts the watcher and streams its combined output into
+// dev.events until it exits.
+func startDevWatcher(dev *devSession) tea.Cmd {
+	return func() tea.Msg {
+		stdout, err := dev.cmd.StdoutPipe()
+		if err == nil {
+			dev.cmd.Stderr = dev.cmd.Stdout
+			err = dev.cmd.Start()
+		}
+		if err != nil {
+			dev.events <- devEvent{exited: true, err: fmt.Errorf("failed to start bun run dev: %w", err)}
+			return nil
+		}
+
+		go func() {
+			scanner := bufio.NewScanner(stdout)
+			for scanner.Scan() {
+				dev.events <- devEvent{line: stripANSI(scanner.Text())}
+			}
+			dev.events <- devEvent{exited: true, err: dev.cmd.Wait()}
+		}()
+		return nil
+	}
+}
+
+func waitDevEvent(events chan devEvent) tea.Cmd {
+	return func() tea.Msg {
+		return devEventMsg(<-events)
+	}
+}
+
+func (m model) handleDevEvent(msg devEventMsg) (tea.Model, tea.Cmd) {
+	dev := m.dev
+	if dev == nil {
+		return m, nil
+	}
+
+	if msg.exited {
+		if dev.status != devStopped {
+			dev.status = devFailed
+			reason := "bun run dev exited"
+			if msg.err != nil {
+				reason += ": " + msg.err.Error()
+			}
+			dev.errors = appendCapped(dev.errors, reason, devMaxErrors)
+		}
+		return m, nil
+	}
+
+	line := strings.TrimSpace(msg.line)
+	if line != "" {
+		dev.output = appendCapped(dev.output, line, devMaxOutput)
+	}
+	switch {
+	case strings.HasPrefix(strings.ToLower(line), "error"):
+		dev.status = devFailed
+		dev.errors = appendCapped(dev.errors, line, devMaxErrors)
+	case devBundledRegex.MatchString(line):
+		dev.builds++
+		dev.lastBuild = time.Now()
+		dev.errors = nil
+		if err := dev.linkBuild(); err != nil {
+			dev.status = devFailed
+			dev.errors = append(dev.errors, err.Error())
+		} else {
+			dev.status = devOK
+		}
+	}
+	return m, waitDevEvent(dev.events)
+}
+
+// linkBuild re-validates the freshly built entry and, the first time it is
+// good, points the plugin symlink at it and makes the local entry the only
+// cursor-acp entry in the plugin array, so OpenCode loads the link.
+func (dev *devSession) linkBuild() error {
+	if _, err := pluginEntryPath(dev.dir); err != nil {
+		return err
+	}
+	if dev.linked {
+		return nil
+	}
+	if err := linkPluginFile(dev.entry, dev.linkPath, linkModeSymlink); err != nil {
+		return err
+	}
+	dev.linked = true
+	return dev.usePluginLink()
+}
+
+// usePluginLink switches the plugin array to the local entry, remembering the
+// entries it replaces. A missing config is left alone.
+func (dev *devSession) usePluginLink() error {
+	config, err := opencodeconfig.Load(dev.configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	prev := append([]opencodeconfig.PluginEntry(nil), config.Plugin...)
+	if removed := config.SetCursorACPPlugin(opencodeconfig.ProviderID); len(removed) == 0 && len(config.Plugin) == len(prev) {
+		return nil
+	}
+	if err := backupConfigToDisk(dev.configPath, "Dev link"); err != nil {
+		return err
+	}
+	if err := config.Save(dev.configPath); err != nil {
+		return err
+	}
+	dev.prevPlugins = prev
+	dev.pluginsChanged = true
+	return nil
+}
+
+// stop ends the watcher; its exit event arrives later and is ignored.
+func (dev *devSession) stop() {
+	dev.status = devStopped
+	if dev.cmd != nil && dev.cmd.Process != nil {
+		_ = killProcessGroup(dev.cmd)
+	}
+}
+
+// restore puts the symlink and the plugin array back the way they were
+// before dev mode.
+func (dev *devSession) restore() error {
+	if !dev.linked {
+		return nil
+	}
+	if err := os.Remove(dev.linkPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	switch {
+	case dev.prevCopy != nil:
+		if err := os.WriteFile(dev.linkPath, dev.prevCopy, 0644); err != nil {
+			return err
+		}
+	case dev.prevTarget != "":
+		if err := os.Symlink(dev.prevTarget, dev.linkPath); err != nil {
+			return fmt.Errorf("failed to restore symlink: %w", err)
+		}
+	}
+
+	if !dev.pluginsChanged {
+		return nil
+	}
+	config, err := opencodeconfig.Load(dev.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to restore plugin entries: %w", err)
+	}
+	config.Plugin = dev.prevPlugins
+	if err := config.Save(dev.configPath); err != nil {
+		return fmt.Errorf("failed to restore plugin entries: %w", err)
+	}
+	return nil
+}
+
+// needsRestore reports whether dev mode changed anything worth offering to
+// put back.
+func (dev *devSession) needsRestore() bool {
+	return dev.linked && (dev.prevTarget != dev.entry || dev.pluginsChanged)
+}
+
+// describePluginEntries lists the cursor-acp entries among plugins.
+func describePluginEntries(plugins []opencodeconfig.PluginEntry) string {
+	var specs []string
+	for _, p := range plugins {
+		if p.Kind() != opencodeconfig.PluginOther {
+			specs = append(specs, p.Spec)
+		}
+	}
+	if len(specs) == 0 {
+		return "none"
+	}
+	return strings.Join(specs, ", ")
+}
+
+func appendCapped(list []string, item string, limit int) []string {
+	list = append(list, item)
+	if len(list) > limit {
+		list = list[len(list)-limit:]
+	}
+	return list
+}
+
+func (m model) handleDevKeys(key string) (tea.Model, tea.Cmd) {
+	dev := m.dev
+	if dev == nil {
+		return m, tea.Quit
+	}
+
+	if !dev.quitting {
+		if key == "q" || key == "ctrl+c" || key == "esc" {
+			dev.stop()
+			if !dev.needsRestore() {
+				return m, tea.Quit
+			}
+			dev.quitting = true
+		}
+		return m, nil
+	}
+
+	switch key {
+	case "y":
+		if err := dev.restore(); err != nil {
+			m.errors = append(m.errors, err.Error())
+		}
+		return m, tea.Quit
+	case "n", "enter", "ctrl+c", "esc":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m model) renderDev() string {
+	dev := m.dev
+	if dev == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(Primary).Render("Dev link: " + dev.dir))
+	b.WriteString("\n\n")
+
+	if dev.linked {
+		b.WriteString(fmt.Sprintf("Linked:  %s → %s\n", dev.linkPath, dev.entry))
+	} else {
+		b.WriteString(fmt.Sprintf("Linking: %s after the first successful build\n", dev.linkPath))
+	}
+
+	var status string
+	switch dev.status {
+	case devStarting:
+		status = m.spinner.View() + " building…"
+	case devOK:
+		status = checkMark.String() + fmt.Sprintf(" build #%d ok at %s, watching for changes", dev.builds, dev.lastBuild.Format("15:04:05"))
+	case devFailed:
+		status = failMark.String() + " build failed, fix and save to retry"
+	case devStopped:
+		status = skipMark.String() + " watcher stopped"
+	}
+	b.WriteString("Status:  " + status + "\n")
+
+	if dev.warning != "" {
+		b.WriteString("\n" + lipgloss.NewStyle().Foreground(WarningColor).Render("⚠ "+dev.warning) + "\n")
+	}
+
+	if len(dev.errors) > 0 {
+		b.WriteString("\n")
+		for _, e := range dev.errors {
+			b.WriteString(lipgloss.NewStyle().Foreground(ErrorColor).Render("  "+e) + "\n")
+		}
+	}
+
+	if len(dev.output) > 0 {
+		b.WriteString("\n")
+		for _, line := range dev.output {
+			b.WriteString(lipgloss.NewStyle().Foreground(FgMuted).Render("  "+line) + "\n")
+		}
+	}
+
+	if dev.quitting {
+		b.WriteString("\n")
+		prev := dev.prevTarget
+		switch {
+		case dev.prevCopy != nil:
+			prev = "a copied plugin file"
+		case prev == "":
+			prev = "no link"
+		}
+		question := fmt.Sprintf("Restore the previous plugin link (%s)", prev)
+		if dev.pluginsChanged {
+			question += fmt.Sprintf(" and plugin entries (%s)", describePluginEntries(dev.prevPlugins))
+		}
+		b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(WarningColor).Render(question + "? [y/n]"))
+	}
+
+	return b.String()
+}
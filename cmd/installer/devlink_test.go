@@ -0,0 +1,148 @@
+// cmd/installer/devlink_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+func pluginSpecs(plugins []opencodeconfig.PluginEntry) []string {
+	specs := make([]string, len(plugins))
+	for i, p := range plugins {
+		specs[i] = p.Spec
+	}
+	return specs
+}
+
+func newDevTestModel(t *testing.T) (*model, *devSession) {
+	t.Helper()
+	m := newTestModel(t)
+	if err := os.MkdirAll(m.pluginDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	dev := &devSession{
+		dir:        m.projectDir,
+		entry:      filepath.Join(m.projectDir, "dist", "plugin-entry.js"),
+		linkPath:   pluginFilePath(m.pluginDir),
+		configPath: m.configPath,
+		events:     make(chan devEvent, 1),
+	}
+	m.dev = dev
+	m.step = stepDev
+	return m, dev
+}
+
+func TestDevLinkBuild(t *testing.T) {
+	m, dev := newDevTestModel(t)
+	writeTestConfig(t, m.configPath, `{"plugin": ["other", "@rama_nigg/open-cursor@2.3.10"]}`)
+
+	if err := dev.linkBuild(); err == nil {
+		t.Fatal("linked a checkout without a build")
+	}
+	if dev.linked {
+		t.Fatal("marked linked without a build")
+	}
+
+	writePluginEntry(t, m.projectDir)
+	if err := dev.linkBuild(); err != nil {
+		t.Fatal(err)
+	}
+	if target, _ := os.Readlink(dev.linkPath); target != dev.entry {
+		t.Errorf("symlink -> %q, want %q", target, dev.entry)
+	}
+	if got := pluginSpecs(loadTestConfig(t, m.configPath).Plugin); !reflect.DeepEqual(got, []string{"other", "cursor-acp"}) {
+		t.Errorf("plugins = %q", got)
+	}
+	if !dev.pluginsChanged || !dev.needsRestore() {
+		t.Error("plugin array change not remembered")
+	}
+
+	// Later builds only re-validate.
+	if err := os.Remove(dev.linkPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := dev.linkBuild(); err != nil {
+		t.Fatal(err)
+	}
+	if pathExists(dev.linkPath) {
+		t.Error("relinked on a later build")
+	}
+}
+
+func TestHandleDevEvent(t *testing.T) {
+	m, dev := newDevTestModel(t)
+	writeTestConfig(t, m.configPath, `{"plugin": ["cursor-acp"]}`)
+	writePluginEntry(t, m.projectDir)
+
+	send := func(msg devEventMsg) {
+		t.Helper()
+		next, _ := m.handleDevEvent(msg)
+		*m = next.(model)
+	}
+
+	send(devEventMsg{line: "error: Could not resolve \"./missing\""})
+	if dev.status != devFailed || len(dev.errors) != 1 || dev.linked {
+		t.Fatalf("after error: status=%d errors=%q linked=%v", dev.status, dev.errors, dev.linked)
+	}
+
+	send(devEventMsg{line: "Bundled 42 modules in 18ms"})
+	if dev.status != devOK || dev.builds != 1 || len(dev.errors) != 0 || !dev.linked {
+		t.Fatalf("after build: status=%d builds=%d errors=%q linked=%v", dev.status, dev.builds, dev.errors, dev.linked)
+	}
+	if dev.pluginsChanged {
+		t.Error("rewrote a plugin array that already had the local entry")
+	}
+	if len(dev.output) != 2 {
+		t.Errorf("output = %q", dev.output)
+	}
+
+	send(devEventMsg{exited: true})
+	if dev.status != devFailed || len(dev.errors) != 1 {
+		t.Errorf("after exit: status=%d errors=%q", dev.status, dev.errors)
+	}
+
+	// The exit that follows stop is expected.
+	dev.errors = nil
+	dev.stop()
+	send(devEventMsg{exited: true})
+	if dev.status != devStopped || len(dev.errors) != 0 {
+		t.Errorf("after stop: status=%d errors=%q", dev.status, dev.errors)
+	}
+}
+
+func TestDevQuitRestoresLinkAndPlugins(t *testing.T) {
+	m, dev := newDevTestModel(t)
+	writeTestConfig(t, m.configPath, `{"plugin": ["@rama_nigg/open-cursor@latest", "other"]}`)
+	prev := writePluginEntry(t, t.TempDir())
+	if err := os.Symlink(prev, dev.linkPath); err != nil {
+		t.Fatal(err)
+	}
+	dev.prevTarget = prev
+	writePluginEntry(t, m.projectDir)
+	if err := dev.linkBuild(); err != nil {
+		t.Fatal(err)
+	}
+
+	next, cmd := m.handleKeyPress(tea.KeyMsg{Type: tea.KeyCtrlC})
+	*m = next.(model)
+	if cmd != nil || !dev.quitting {
+		t.Fatal("ctrl+c quit without offering to restore")
+	}
+
+	next, _ = m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	*m = next.(model)
+	if len(m.errors) > 0 {
+		t.Fatal(m.errors)
+	}
+	if target, _ := os.Readlink(dev.linkPath); target != prev {
+		t.Errorf("symlink -> %q, want %q", target, prev)
+	}
+	if got := pluginSpecs(loadTestConfig(t, m.configPath).Plugin); !reflect.DeepEqual(got, []string{"@rama_nigg/open-cursor@latest", "other"}) {
+		t.Errorf("plugins = %q", got)
+	}
+}
@@ -0,0 +1,489 @@
+// cmd/installer/doctor.go
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+// Oldest tool versions the installer and plugin are tested with.
+var toolMinimums = []struct {
+	name, min string
+	required  bool // bun builds and installs; node and npm only back npm installs
+}{
+	{"bun", "1.1.0", true},
+	{"node", "18.0.0", false},
+	{"npm", "9.0.0", false},
+}
+
+// Disk space below these, in bytes, is reported.
+const (
+	diskSpaceWarning = 500 << 20
+	diskSpaceError   = 100 << 20
+)
+
+// severity orders doctor findings.
+type severity int
+
+const (
+	severityOK severity = iota
+	severityInfo
+	severityWarning
+	severityError
+)
+
+func (s severity) String() string {
+	return [...]string{"ok", "info", "warning", "error"}[s]
+}
+
+func (s severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s severity) mark() string {
+	switch s {
+	case severityOK:
+		return checkMark.String()
+	case severityError:
+		return failMark.String()
+	default:
+		return skipMark.String()
+	}
+}
+
+// finding is one doctor result. Fix says what would resolve it; apply does
+// it when the fix can be automated.
+type finding struct {
+	Check    string   `json:"check"`
+	Severity severity `json:"severity"`
+	Message  string   `json:"message"`
+	Fix      string   `json:"fix,omitempty"`
+	apply    func(*model) error
+}
+
+// runDoctor runs every diagnostic against m's install.
+func runDoctor(m *model) []finding {
+	var findings []finding
+	findings = append(findings, checkToolVersions()...)
+	findings = append(findings, checkPathIssues()...)
+	findings = append(findings, checkConfigDirWritable(), checkDiskSpace(), checkCursorAuth(), checkProxyPort(m), checkOpenCodeResolvesPlugin(m))
+	findings = append(findings, checkLegacyPieces(m)...)
+	findings = append(findings, checkDuplicateCopies(m))
+	return findings
+}
+
+var versionPattern = regexp.MustCompile(`\d+\.\d+\.\d+`)
+
+func checkToolVersions() []finding {
+	var findings []finding
+	for _, tool := range toolMinimums {
+		f := finding{Check: tool.name}
+		if !commandExists(tool.name) {
+			f.Severity, f.Message = severityWarning, "not found"
+			if tool.required {
+				f.Severity = severityError
+			}
+			f.Fix = installHint(tool.name)
+			findings = append(findings, f)
+			continue
+		}
+		out, _ := exec.Command(tool.name, "--version").Output()
+		version := versionPattern.FindString(string(out))
+		switch {
+		case version == "":
+			f.Severity, f.Message = severityWarning, "version unknown: "+strings.TrimSpace(string(out))
+		case compareVersions(version, tool.min) < 0:
+			f.Severity, f.Message = severityWarning, fmt.Sprintf("%s is older than the minimum %s", version, tool.min)
+			if tool.required {
+				f.Severity = severityError
+			}
+			f.Fix = installHint(tool.name)
+		default:
+			f.Message = version
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+func installHint(tool string) string {
+	switch tool {
+	case "bun":
+		return "curl -fsSL https://bun.sh/install | bash"
+	case "cursor-agent":
+		return "curl -fsS https://cursor.com/install | bash"
+	case "opencode":
+		return "curl -fsSL https://opencode.ai/install | bash"
+	default:
+		return "install Node.js 18 or newer (includes npm)"
+	}
+}
+
+// checkPathIssues looks for tools installed to their usual places but not
+// reachable through PATH.
+func checkPathIssues() []finding {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return nil
+	}
+	home := filepath.Dir(configDir)
+	known := []struct{ name, path string }{
+		{"bun", filepath.Join(home, ".bun", "bin", "bun")},
+		{"cursor-agent", filepath.Join(home, ".local", "bin", "cursor-agent")},
+		{"opencode", filepath.Join(home, ".opencode", "bin", "opencode")},
+	}
+	if prefix := npmGlobalPrefix(); prefix != "" {
+		known = append(known, struct{ name, path string }{"open-cursor", filepath.Join(prefix, "bin", "open-cursor")})
+	}
+
+	var findings []finding
+	for _, k := range known {
+		if !pathExists(k.path) || commandExists(k.name) {
+			continue
+		}
+		dir := filepath.Dir(k.path)
+		findings = append(findings, finding{
+			Check:    "PATH",
+			Severity: severityWarning,
+			Message:  fmt.Sprintf("%s is installed at %s but %s is not on PATH", k.name, k.path, dir),
+			Fix:      fmt.Sprintf("add `export PATH=\"%s:$PATH\"` to your shell profile", dir),
+		})
+	}
+	if len(findings) == 0 {
+		findings = append(findings, finding{Check: "PATH", Message: "installed tools are reachable"})
+	}
+	return findings
+}
+
+func npmGlobalPrefix() string {
+	if !commandExists("npm") {
+		return ""
+	}
+	out, err := exec.Command("npm", "prefix", "-g").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func checkConfigDirWritable() finding {
+	f := finding{Check: "Config dir"}
+	dir, err := opencodeConfigDir()
+	if err != nil {
+		f.Severity, f.Message = severityError, err.Error()
+		return f
+	}
+	probe := dir
+	for !pathExists(probe) && filepath.Dir(probe) != probe {
+		probe = filepath.Dir(probe)
+	}
+	tmp, err := os.CreateTemp(probe, ".opencode-cursor-doctor-*")
+	if err != nil {
+		f.Severity, f.Message = severityError, fmt.Sprintf("%s is not writable: %v", probe, err)
+		f.Fix = fmt.Sprintf("sudo chown -R %s %s", getActualUser(), probe)
+		return f
+	}
+	tmp.Close()
+	os.Remove(tmp.Name())
+	f.Message = dir + " is writable"
+	return f
+}
+
+func checkDiskSpace() finding {
+	f := finding{Check: "Disk space"}
+	dir, err := opencodeConfigDir()
+	if err != nil {
+		f.Severity, f.Message = severityInfo, err.Error()
+		return f
+	}
+	for !pathExists(dir) && filepath.Dir(dir) != dir {
+		dir = filepath.Dir(dir)
+	}
+	free, err := freeDiskSpace(dir)
+	if err != nil {
+		f.Severity, f.Message = severityInfo, "could not check: "+err.Error()
+		return f
+	}
+	f.Message = fmt.Sprintf("%d MB free in %s", free>>20, dir)
+	switch {
+	case free < diskSpaceError:
+		f.Severity = severityError
+	case free < diskSpaceWarning:
+		f.Severity = severityWarning
+	default:
+		return f
+	}
+	f.Fix = "free some space; bun install needs room for node_modules"
+	if backups, _ := listBackups(); len(backups) > 0 {
+		f.Fix += " (`installer backups prune --keep 5` clears old config backups)"
+	}
+	return f
+}
+
+// cursorAuthPaths are where cursor-agent keeps its login, in the order the
+// plugin looks (src/auth.ts getPossibleAuthPaths).
+func cursorAuthPaths(home string) []string {
+	var dirs []string
+	if runtime.GOOS == "darwin" {
+		dirs = []string{filepath.Join(home, ".cursor"), filepath.Join(home, ".config", "cursor")}
+	} else {
+		dirs = []string{filepath.Join(home, ".config", "cursor")}
+		if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" && xdg != filepath.Join(home, ".config") {
+			dirs = append(dirs, filepath.Join(xdg, "cursor"))
+		}
+		dirs = append(dirs, filepath.Join(home, ".cursor"))
+	}
+	var paths []string
+	for _, dir := range dirs {
+		paths = append(paths, filepath.Join(dir, "cli-config.json"), filepath.Join(dir, "auth.json"))
+	}
+	return paths
+}
+
+func checkCursorAuth() finding {
+	f := finding{Check: "cursor-agent auth"}
+	configDir, err := getConfigDir()
+	if err != nil {
+		f.Severity, f.Message = severityInfo, err.Error()
+		return f
+	}
+	for _, path := range cursorAuthPaths(filepath.Dir(configDir)) {
+		if pathExists(path) {
+			f.Message = path
+			return f
+		}
+	}
+	f.Severity, f.Message = severityWarning, "no auth file found; the plugin can't authenticate"
+	f.Fix = "run `cursor-agent login`"
+	if commandExists("cursor-agent") {
+		f.apply = func(*model) error {
+			cmd := exec.Command("cursor-agent", "login")
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+			return cmd.Run()
+		}
+	}
+	return f
+}
+
+// checkProxyPort checks the proxy's port is free or held by the proxy itself.
+func checkProxyPort(m *model) finding {
+	baseURL := opencodeconfig.DefaultBaseURL
+	if config, err := opencodeconfig.Load(m.configPath); err == nil {
+		if p := config.CursorACP(); p != nil && p.Options != nil && p.Options.BaseURL != "" {
+			baseURL = p.Options.BaseURL
+		}
+	}
+	f := finding{Check: "Proxy port"}
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Port() == "" {
+		f.Severity, f.Message = severityInfo, "no port in "+baseURL
+		return f
+	}
+	addr := net.JoinHostPort(u.Hostname(), u.Port())
+
+	ln, err := net.Listen("tcp", addr)
+	if err == nil {
+		ln.Close()
+		f.Message = addr + " is free"
+		return f
+	}
+	if proxyAnswers(baseURL) {
+		f.Message = addr + " is held by the cursor-acp proxy (OpenCode is running)"
+		return f
+	}
+	f.Severity, f.Message = severityError, addr+" is in use by another process; the proxy can't start"
+	f.Fix = fmt.Sprintf("stop the process on port %s (`lsof -i :%s`) or change options.baseURL", u.Port(), u.Port())
+	return f
+}
+
+// proxyAnswers reports whether baseURL/models returns an OpenAI-style model
+// list, as the plugin's proxy does.
+func proxyAnswers(baseURL string) bool {
+	client := &http.Client{Timeout: proxyTimeout}
+	resp, err := client.Get(strings.TrimRight(baseURL, "/") + "/models")
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Data []json.RawMessage `json:"data"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	return json.Unmarshal(data, &body) == nil && body.Data != nil
+}
+
+func checkOpenCodeResolvesPlugin(m *model) finding {
+	f := finding{Check: "OpenCode plugin"}
+	if !commandExists("opencode") {
+		f.Severity, f.Message = severityWarning, "opencode not found"
+		f.Fix = installHint("opencode")
+		return f
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "opencode", "models").CombinedOutput()
+	if err != nil {
+		f.Severity, f.Message = severityError, fmt.Sprintf("`opencode models` failed: %v", err)
+		return f
+	}
+	if strings.Contains(string(out), opencodeconfig.ProviderID+"/") {
+		f.Message = "opencode models lists cursor-acp"
+		return f
+	}
+	f.Severity, f.Message = severityError, "opencode models doesn't list cursor-acp; the plugin isn't loading"
+	f.Fix = "run the repair fixes (also available as `r` in the installer)"
+	f.apply = applyRepairFixes
+	return f
+}
+
+// applyRepairFixes runs the fix of every broken component repair finds.
+// Prompts are skipped, so conflicting plugin entries are left alone.
+func applyRepairFixes(m *model) error {
+	var failed []string
+	for _, h := range inspectInstall(m) {
+		if h.fix == nil {
+			continue
+		}
+		if err := h.fix(m); err != nil {
+			failed = append(failed, h.name+": "+err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// checkLegacyPieces reports what any migration would clean up, including
+// ones recorded as run whose artifacts came back.
+func checkLegacyPieces(m *model) []finding {
+	var findings []finding
+	for _, p := range detectMigrations(m, true) {
+		findings = append(findings, finding{
+			Check:    "Legacy",
+			Severity: severityWarning,
+			Message:  strings.Join(p.found, ", "),
+			Fix:      p.description,
+			apply:    p.apply,
+		})
+	}
+	if len(findings) == 0 {
+		findings = append(findings, finding{Check: "Legacy", Message: "nothing left from older versions"})
+	}
+	return findings
+}
+
+// checkDuplicateCopies reports copies of the plugin OpenCode doesn't load.
+func checkDuplicateCopies(m *model) finding {
+	f := finding{Check: "Plugin copies"}
+	copies := findPluginCopies(m)
+	unused := unusedCopies(copies)
+	if len(unused) == 0 {
+		f.Message = fmt.Sprintf("%d installed, none unused", len(copies))
+		return f
+	}
+	var list []string
+	for _, c := range unused {
+		list = append(list, fmt.Sprintf("%s %s (%s)", c.Source, c.Version, c.Dir))
+	}
+	f.Severity = severityWarning
+	f.Message = fmt.Sprintf("%d of %d copies aren't loaded by OpenCode: %s", len(unused), len(copies), strings.Join(list, ", "))
+	f.Fix = "remove the unused copies (`installer duplicates --remove` to pick)"
+	f.apply = func(m *model) error {
+		for _, c := range unused {
+			if err := c.remove(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return f
+}
+
+func cmdDoctor(args []string) error {
+	fs, configPath := newFlagSet("doctor")
+	asJSON := fs.Bool("json", false, "print findings as JSON")
+	fix := fs.Bool("fix", false, "offer to apply the automatic fixes")
+	yes := fs.Bool("yes", false, "with --fix, apply without asking")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	m := newHeadlessModel(*configPath)
+	defer m.cancel()
+	findings := runDoctor(&m)
+
+	if *asJSON {
+		data, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		printFindings(os.Stdout, findings)
+	}
+
+	problems, fixable := 0, 0
+	for _, f := range findings {
+		if f.Severity >= severityWarning {
+			problems++
+		}
+		if f.Severity > severityOK && f.apply != nil {
+			fixable++
+		}
+	}
+
+	if *fix {
+		in := bufio.NewReader(os.Stdin)
+		for _, f := range findings {
+			if f.Severity == severityOK || f.apply == nil {
+				continue
+			}
+			if !*yes {
+				fmt.Printf("\n%s: %s\nFix: %s? [y/N] ", f.Check, f.Message, f.Fix)
+				answer, _ := in.ReadString('\n')
+				if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+					continue
+				}
+			}
+			if err := f.apply(&m); err != nil {
+				fmt.Printf("%s %s: %v\n", failMark.String(), f.Fix, err)
+			} else {
+				fmt.Printf("%s %s\n", checkMark.String(), f.Fix)
+			}
+		}
+		return nil
+	}
+
+	if !*asJSON && fixable > 0 {
+		fmt.Printf("\n%d finding(s) can be fixed automatically: run `installer doctor --fix`\n", fixable)
+	}
+	if problems > 0 {
+		return fmt.Errorf("%d problem(s) found", problems)
+	}
+	return nil
+}
+
+func printFindings(w io.Writer, findings []finding) {
+	for _, f := range findings {
+		fmt.Fprintf(w, "  %s %s: %s\n", f.Severity.mark(), f.Check, f.Message)
+		if f.Fix != "" && f.Severity > severityOK {
+			fmt.Fprintf(w, "      fix: %s\n", f.Fix)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// cmd/installer/doctor_test.go
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+func TestCheckProxyPort(t *testing.T) {
+	m := newTestModel(t)
+
+	other, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	writeTestConfig(t, m.configPath, `{"provider": {"cursor-acp": {"options": {"baseURL": "http://`+other.Addr().String()+`/v1"}}}}`)
+	if f := checkProxyPort(m); f.Severity != severityError || f.Fix == "" {
+		t.Errorf("port held by another process = %+v", f)
+	}
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"object":"list","data":[]}`))
+	}))
+	defer proxy.Close()
+	writeTestConfig(t, m.configPath, `{"provider": {"cursor-acp": {"options": {"baseURL": "`+proxy.URL+`/v1"}}}}`)
+	if f := checkProxyPort(m); f.Severity != severityOK {
+		t.Errorf("port held by the proxy = %+v", f)
+	}
+
+	addr := other.Addr().String()
+	other.Close()
+	writeTestConfig(t, m.configPath, `{"provider": {"cursor-acp": {"options": {"baseURL": "http://`+addr+`/v1"}}}}`)
+	if f := checkProxyPort(m); f.Severity != severityOK || !strings.Contains(f.Message, "free") {
+		t.Errorf("free port = %+v", f)
+	}
+}
+
+func TestCheckLegacyPieces(t *testing.T) {
+	m := newTestModel(t)
+	writeTestConfig(t, m.configPath, `{"plugin": ["cursor-acp", "`+opencodeconfig.LegacyAuthPlugin+`"]}`)
+	old := filepath.Join(filepath.Dir(m.configPath), "node_modules", "cursor-acp")
+	if err := os.MkdirAll(filepath.Dir(old), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/gone", old); err != nil {
+		t.Fatal(err)
+	}
+
+	findings := checkLegacyPieces(m)
+	if len(findings) != 2 {
+		t.Fatalf("findings = %+v", findings)
+	}
+	for _, f := range findings {
+		if f.Severity != severityWarning || f.apply == nil {
+			t.Errorf("%s: want a warning with a fix", f.Message)
+			continue
+		}
+		if err := f.apply(m); err != nil {
+			t.Fatalf("fix %q: %v", f.Message, err)
+		}
+	}
+
+	if loadTestConfig(t, m.configPath).HasPlugin(opencodeconfig.PluginEntry.IsLegacyAuth) {
+		t.Error("legacy auth entry still in the plugin array")
+	}
+	if _, err := os.Lstat(old); !os.IsNotExist(err) {
+		t.Errorf("old node_modules link still there: %v", err)
+	}
+	if findings := checkLegacyPieces(m); len(findings) != 1 || findings[0].Severity != severityOK {
+		t.Errorf("after fixes = %+v", findings)
+	}
+}
+
+func TestCheckCursorAuth(t *testing.T) {
+	newTestModel(t)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if f := checkCursorAuth(); f.Severity != severityWarning {
+		t.Errorf("no auth file = %+v", f)
+	}
+
+	home := os.Getenv("HOME")
+	path := cursorAuthPaths(home)[1]
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if f := checkCursorAuth(); f.Severity != severityOK || f.Message != path {
+		t.Errorf("with %s = %+v", path, f)
+	}
+}
+
+func TestFindingJSON(t *testing.T) {
+	data, err := json.Marshal(finding{Check: "bun", Severity: severityError, Message: "not found", Fix: "install bun"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["severity"] != "error" || got["check"] != "bun" || got["fix"] != "install bun" {
+		t.Errorf("json = %s", data)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+// cmd/installer/doctor_unix.go
+package main
+
+import "syscall"
+
+// freeDiskSpace is the space available to this user on dir's filesystem.
+func freeDiskSpace(dir string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return st.Bavail * uint64(st.Bsize), nil
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+// cmd/installer/doctor_windows.go
+package main
+
+import "errors"
+
+func freeDiskSpace(dir string) (uint64, error) {
+	return 0, errors.New("not supported on Windows")
+}
@@ -0,0 +1,257 @@
+// cmd/installer/duplicates.go
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+// pluginCopy is one installed copy of the plugin package.
+type pluginCopy struct {
+	Source  pluginSource `json:"source"`
+	Version string       `json:"version"`
+	Dir     string       `json:"dir"`
+	Loaded  bool         `json:"loaded"`
+	// Kept says why an unused copy isn't offered for removal.
+	Kept   string `json:"kept,omitempty"`
+	remove func(m *model) error
+}
+
+// bunGlobalDir is where `bun add -g` puts packages.
+func bunGlobalDir() (string, error) {
+	if dir := os.Getenv("BUN_INSTALL"); dir != "" {
+		return filepath.Join(dir, "install", "global", "node_modules"), nil
+	}
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configDir), ".bun", "install", "global", "node_modules"), nil
+}
+
+// findPluginCopies lists every copy of the plugin package this installer
+// knows where to look for, and marks the ones OpenCode loads.
+func findPluginCopies(m *model) []pluginCopy {
+	var copies []pluginCopy
+	seen := make(map[string]bool)
+	add := func(source pluginSource, dir string, remove func(*model) error) {
+		name, version, err := readPackageIdentity(filepath.Join(dir, "package.json"))
+		if err != nil || name != npmPackage {
+			return
+		}
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err != nil || seen[resolved] {
+			return
+		}
+		seen[resolved] = true
+		copies = append(copies, pluginCopy{Source: source, Version: version, Dir: dir, remove: remove})
+	}
+
+	if root := npmGlobalRoot(); root != "" {
+		add(sourceNpmGlobal, filepath.Join(root, filepath.FromSlash(npmPackage)), packageManagerRemove("npm", "uninstall", "-g", npmPackage))
+	}
+	if root, err := bunGlobalDir(); err == nil {
+		add(sourceBunGlobal, filepath.Join(root, filepath.FromSlash(npmPackage)), packageManagerRemove("bun", "remove", "-g", npmPackage))
+	}
+	if managed, err := getManagedDir(); err == nil {
+		add(sourceManagedCopy, managed, removeManagedClone(managed))
+		add(sourceManagedCopy, filepath.Join(managed, "repo"), removeDir(filepath.Join(managed, "repo")))
+		releases, _ := filepath.Glob(filepath.Join(managed, "releases", "*"))
+		for _, dir := range releases {
+			add(sourceManagedCopy, dir, removeDir(dir))
+		}
+	}
+	if modulesDir, err := getOpenCodeModulesDir(); err == nil {
+		add(sourceOpenCodeCache, filepath.Join(modulesDir, filepath.FromSlash(npmPackage)), clearOpenCodePluginCache)
+	}
+	if isPluginCheckout(m.projectDir) {
+		add(sourceLocalBuild, m.projectDir, nil)
+	}
+	for _, dir := range loadedPluginDirs(m) {
+		add(sourceLocalBuild, dir, nil)
+	}
+
+	loaded := make(map[string]bool)
+	for _, dir := range loadedPluginDirs(m) {
+		loaded[dir] = true
+	}
+	project, _ := filepath.EvalSymlinks(m.projectDir)
+	for i := range copies {
+		c := &copies[i]
+		resolved, _ := filepath.EvalSymlinks(c.Dir)
+		switch {
+		case loaded[resolved]:
+			c.Loaded = true
+		case resolved == project:
+			c.Kept = "the checkout this installer runs from"
+		case c.remove == nil:
+			c.Kept = "not managed by the installer"
+		case c.Source == sourceNpmGlobal && !commandExists("npm"), c.Source == sourceBunGlobal && !commandExists("bun"):
+			c.Kept = c.Source.String() + ", but its package manager isn't on PATH"
+		}
+	}
+	return copies
+}
+
+// loadedPluginDirs are the resolved package roots OpenCode loads: the target
+// of plugin/cursor-acp.js (or, for a copy, the entry the manifest recorded),
+// and OpenCode's cached npm install when the plugin array names the package.
+func loadedPluginDirs(m *model) []string {
+	var dirs []string
+	pluginFile := pluginFilePath(m.pluginDir)
+	target := ""
+	if mode := detectLinkMode(pluginFile); mode == linkModeCopy {
+		if mf, err := loadInstallManifest(); err == nil {
+			target = mf.PluginEntry
+		}
+	} else if mode != "" {
+		target, _ = filepath.EvalSymlinks(pluginFile)
+	}
+	if target != "" {
+		if root, _ := findPackageRoot(filepath.Dir(target)); root != "" {
+			if resolved, err := filepath.EvalSymlinks(root); err == nil {
+				dirs = append(dirs, resolved)
+			}
+		}
+	}
+
+	config, err := opencodeconfig.Load(m.configPath)
+	if err == nil && config.HasPlugin(func(e opencodeconfig.PluginEntry) bool { return e.Kind() == opencodeconfig.PluginNPM }) {
+		if modulesDir, err := getOpenCodeModulesDir(); err == nil {
+			if resolved, err := filepath.EvalSymlinks(filepath.Join(modulesDir, filepath.FromSlash(npmPackage))); err == nil {
+				dirs = append(dirs, resolved)
+			}
+		}
+	}
+	return dirs
+}
+
+func packageManagerRemove(name string, args ...string) func(*model) error {
+	return func(m *model) error {
+		return runCommand(name+" "+strings.Join(args, " "), exec.Command(name, args...), m.logFile)
+	}
+}
+
+func removeDir(dir string) func(*model) error {
+	return func(*model) error {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", dir, err)
+		}
+		return nil
+	}
+}
+
+// removeManagedClone removes the clone install.sh makes at the top of the
+// managed directory, leaving the repo/ and releases/ the installer keeps
+// there for --from-git and --from-tarball.
+func removeManagedClone(managed string) func(*model) error {
+	return func(*model) error {
+		entries, err := os.ReadDir(managed)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.Name() == "repo" || e.Name() == "releases" {
+				continue
+			}
+			if err := os.RemoveAll(filepath.Join(managed, e.Name())); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", managed, err)
+			}
+		}
+		return nil
+	}
+}
+
+// unusedCopies are the copies that can be removed without changing what
+// OpenCode loads.
+func unusedCopies(copies []pluginCopy) []pluginCopy {
+	var unused []pluginCopy
+	for _, c := range copies {
+		if !c.Loaded && c.Kept == "" {
+			unused = append(unused, c)
+		}
+	}
+	return unused
+}
+
+func printPluginCopies(w io.Writer, copies []pluginCopy) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tVERSION\tDIR\tSTATUS")
+	for _, c := range copies {
+		status := "unused"
+		switch {
+		case c.Loaded:
+			status = "loaded by OpenCode"
+		case c.Kept != "":
+			status = "kept: " + c.Kept
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Source, c.Version, c.Dir, status)
+	}
+	return tw.Flush()
+}
+
+func cmdDuplicates(args []string) error {
+	fs, configPath := newFlagSet("duplicates")
+	asJSON := fs.Bool("json", false, "print the copies as JSON")
+	remove := fs.Bool("remove", false, "offer to remove the copies OpenCode doesn't load")
+	yes := fs.Bool("yes", false, "with --remove, remove without asking")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	m := newHeadlessModel(*configPath)
+	defer m.cancel()
+	copies := findPluginCopies(&m)
+
+	if *asJSON {
+		data, err := json.MarshalIndent(copies, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else if len(copies) == 0 {
+		fmt.Println("No copies of " + npmPackage + " found.")
+		return nil
+	} else if err := printPluginCopies(os.Stdout, copies); err != nil {
+		return err
+	}
+
+	unused := unusedCopies(copies)
+	if !*remove {
+		if !*asJSON && len(unused) > 0 {
+			fmt.Printf("\n%d unused copies: run `installer duplicates --remove` to clean them up\n", len(unused))
+		}
+		return nil
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	var failed int
+	for _, c := range unused {
+		if !*yes {
+			fmt.Printf("Remove %s %s at %s? [y/N] ", c.Source, c.Version, c.Dir)
+			answer, _ := in.ReadString('\n')
+			if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+				continue
+			}
+		}
+		if err := c.remove(&m); err != nil {
+			fmt.Printf("%s %s: %v\n", failMark.String(), c.Dir, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s removed %s\n", checkMark.String(), c.Dir)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d copies could not be removed", failed)
+	}
+	return nil
+}
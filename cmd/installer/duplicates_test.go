@@ -0,0 +1,86 @@
+// cmd/installer/duplicates_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writePluginPackage makes dir look like an installed copy of the plugin.
+func writePluginPackage(t *testing.T, dir, version string) string {
+	t.Helper()
+	entry := writePluginEntry(t, dir)
+	pkg := `{"name": "` + npmPackage + `", "version": "` + version + `"}`
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(pkg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+func TestFindPluginCopies(t *testing.T) {
+	m := newTestModel(t)
+	home := os.Getenv("HOME")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("BUN_INSTALL", filepath.Join(home, ".bun"))
+
+	managed, _ := getManagedDir()
+	writePluginPackage(t, managed, "2.1.0")
+	release := filepath.Join(managed, "releases", "open-cursor-2.3.0")
+	entry := writePluginPackage(t, release, "2.3.0")
+	bunCopy, _ := bunGlobalDir()
+	writePluginPackage(t, filepath.Join(bunCopy, npmPackage), "2.0.0")
+	modulesDir, _ := getOpenCodeModulesDir()
+	cached := filepath.Join(modulesDir, npmPackage)
+	writePluginPackage(t, cached, "2.2.0")
+
+	if err := linkPluginFile(entry, pluginFilePath(m.pluginDir), linkModeSymlink); err != nil {
+		t.Fatal(err)
+	}
+
+	byDir := make(map[string]pluginCopy)
+	for _, c := range findPluginCopies(m) {
+		byDir[c.Dir] = c
+	}
+	if len(byDir) != 4 {
+		t.Fatalf("copies = %+v", byDir)
+	}
+	if c := byDir[release]; !c.Loaded || c.Version != "2.3.0" {
+		t.Errorf("release copy = %+v, want loaded", c)
+	}
+	if c := byDir[filepath.Join(bunCopy, npmPackage)]; c.Source != sourceBunGlobal || c.Kept == "" {
+		t.Errorf("bun copy = %+v, want kept without bun on PATH", c)
+	}
+
+	unused := unusedCopies(findPluginCopies(m))
+	if len(unused) != 2 {
+		t.Fatalf("unused = %+v", unused)
+	}
+	for _, c := range unused {
+		if err := c.remove(m); err != nil {
+			t.Fatalf("remove %s: %v", c.Dir, err)
+		}
+	}
+
+	if pathExists(cached) || pathExists(filepath.Join(managed, "package.json")) {
+		t.Error("unused copies still there")
+	}
+	if !pathExists(entry) {
+		t.Error("removing the managed clone took releases/ with it")
+	}
+}
+
+func TestLoadedPluginDirsNpmEntry(t *testing.T) {
+	m := newTestModel(t)
+	t.Setenv("XDG_CACHE_HOME", "")
+	writeTestConfig(t, m.configPath, `{"plugin": ["`+npmPackage+`@latest"]}`)
+	modulesDir, _ := getOpenCodeModulesDir()
+	cached := filepath.Join(modulesDir, npmPackage)
+	writePluginPackage(t, cached, "2.2.0")
+
+	dirs := loadedPluginDirs(m)
+	if resolved, _ := filepath.EvalSymlinks(cached); len(dirs) != 1 || dirs[0] != resolved {
+		t.Errorf("loaded = %v, want the OpenCode cache copy", dirs)
+	}
+}
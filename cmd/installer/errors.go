@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"strings"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
 )
 
 func summarizeRawOutput(raw string) string {
@@ -96,3 +98,16 @@ func NewValidationError(msg, details string, cause error) *InstallerError {
 		Recoverable: true,
 	}
 }
+
+// formatConfigIssues lists schema and semantic errors one per line, each with
+// its JSON pointer and line:column, for the Details of a validation error.
+func formatConfigIssues(path string, issues []opencodeconfig.Issue) string {
+	var b strings.Builder
+	b.WriteString(path)
+	for _, issue := range issues {
+		if !issue.Warning {
+			b.WriteString("\n" + issue.String())
+		}
+	}
+	return b.String()
+}
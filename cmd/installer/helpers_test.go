@@ -0,0 +1,27 @@
+// cmd/installer/helpers_test.go
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// newTestModel points HOME and the state dir at temp dirs and empties PATH,
+// so the installer only sees what the test sets up.
+func newTestModel(t *testing.T) *model {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SUDO_USER", "")
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("PATH", "")
+	opencodeDir := filepath.Join(home, ".config", "opencode")
+	return &model{
+		configPath:  filepath.Join(opencodeDir, "opencode.json"),
+		pluginDir:   filepath.Join(opencodeDir, "plugin"),
+		projectDir:  t.TempDir(),
+		npmTag:      "latest",
+		linkMode:    linkModeSymlink,
+		backupFiles: make(map[string][]byte),
+	}
+}
@@ -0,0 +1,122 @@
+// cmd/installer/link.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// How plugin/cursor-acp.js refers to the built plugin entry.
+const (
+	// linkModeSymlink is an absolute symlink, the default.
+	linkModeSymlink = "symlink"
+	// linkModeRelative is a symlink relative to the plugin directory, for
+	// config dirs kept in a dotfile repo and checked out elsewhere.
+	linkModeRelative = "relative"
+	// linkModeCopy copies the file, for synced or containerized config dirs
+	// where the entry's path doesn't exist.
+	linkModeCopy = "copy"
+)
+
+var linkModes = []string{linkModeSymlink, linkModeRelative, linkModeCopy}
+
+// nextLinkMode returns the mode after current, wrapping around.
+func nextLinkMode(current string) string {
+	for i, mode := range linkModes {
+		if mode == current {
+			return linkModes[(i+1)%len(linkModes)]
+		}
+	}
+	return linkModes[0]
+}
+
+// pluginFilePath is where OpenCode loads the local plugin from.
+func pluginFilePath(pluginDir string) string {
+	return filepath.Join(pluginDir, "cursor-acp.js")
+}
+
+// linkPluginFile points path at entry using mode, replacing whatever is
+// there.
+func linkPluginFile(entry, path, mode string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create plugin directory: %w", err)
+	}
+	if _, err := os.Lstat(path); err == nil {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+
+	switch mode {
+	case linkModeCopy:
+		if err := copyFile(entry, path); err != nil {
+			return fmt.Errorf("failed to copy plugin: %w", err)
+		}
+		return nil
+	case linkModeRelative:
+		target, err := relativeLinkTarget(entry, path)
+		if err != nil {
+			return err
+		}
+		entry = target
+	}
+	if err := os.Symlink(entry, path); err != nil {
+		return fmt.Errorf("failed to create symlink: %w", err)
+	}
+	return nil
+}
+
+// relativeLinkTarget returns entry relative to the directory holding path.
+// Both are resolved first: the kernel follows a relative link from the real
+// directory, which differs from the lexical one when the config dir is
+// itself a symlink into a dotfile repo.
+func relativeLinkTarget(entry, path string) (string, error) {
+	realEntry, err := filepath.EvalSymlinks(entry)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", entry, err)
+	}
+	realDir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", filepath.Dir(path), err)
+	}
+	rel, err := filepath.Rel(realDir, realEntry)
+	if err != nil {
+		return "", fmt.Errorf("cannot link %s relative to %s: %w", entry, realDir, err)
+	}
+	return rel, nil
+}
+
+// detectLinkMode reports how path was installed, or "" if it doesn't exist.
+func detectLinkMode(path string) string {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return ""
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return linkModeCopy
+	}
+	if target, err := os.Readlink(path); err == nil && !filepath.IsAbs(target) {
+		return linkModeRelative
+	}
+	return linkModeSymlink
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,86 @@
+// cmd/installer/link_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePluginEntry(t *testing.T, dir string) string {
+	t.Helper()
+	entry := filepath.Join(dir, "dist", "plugin-entry.js")
+	if err := os.MkdirAll(filepath.Dir(entry), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(entry, []byte("export default {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+func TestLinkPluginFileModes(t *testing.T) {
+	root := t.TempDir()
+	entry := writePluginEntry(t, filepath.Join(root, "checkout"))
+	path := pluginFilePath(filepath.Join(root, "opencode", "plugin"))
+
+	for _, mode := range linkModes {
+		if err := linkPluginFile(entry, path, mode); err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		if got := detectLinkMode(path); got != mode {
+			t.Errorf("detectLinkMode after %s = %q", mode, got)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != "export default {}\n" {
+			t.Errorf("%s: plugin file reads %q, %v", mode, data, err)
+		}
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := detectLinkMode(path); got != "" {
+		t.Errorf("detectLinkMode of missing file = %q", got)
+	}
+}
+
+// A relative link must resolve from the real plugin directory when the
+// config dir is a symlink into a dotfile repo.
+func TestRelativeLinkThroughSymlinkedConfigDir(t *testing.T) {
+	root := t.TempDir()
+	entry := writePluginEntry(t, filepath.Join(root, "src", "open-cursor"))
+
+	dotfiles := filepath.Join(root, "dotfiles", "opencode")
+	if err := os.MkdirAll(filepath.Join(dotfiles, "plugin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	configDir := filepath.Join(root, "config", "opencode")
+	if err := os.MkdirAll(filepath.Dir(configDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(dotfiles, configDir); err != nil {
+		t.Fatal(err)
+	}
+
+	path := pluginFilePath(filepath.Join(configDir, "plugin"))
+	if err := linkPluginFile(entry, path, linkModeRelative); err != nil {
+		t.Fatal(err)
+	}
+	target, _ := os.Readlink(path)
+	if want := filepath.Join("..", "..", "..", "src", "open-cursor", "dist", "plugin-entry.js"); target != want {
+		t.Errorf("link target = %q, want %q", target, want)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("relative link does not resolve: %v", err)
+	}
+}
+
+func TestNextLinkMode(t *testing.T) {
+	if got := nextLinkMode(linkModeCopy); got != linkModeSymlink {
+		t.Errorf("nextLinkMode(copy) = %q", got)
+	}
+	if got := nextLinkMode(""); got != linkModeSymlink {
+		t.Errorf("nextLinkMode(\"\") = %q", got)
+	}
+}
@@ -0,0 +1,177 @@
+// cmd/installer/lint.go
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+// modelRefIssue is a cursor-acp/<id> reference to a model that is no longer
+// in provider.cursor-acp.models.
+type modelRefIssue struct {
+	path       []string // e.g. ["agent", "build", "model"]
+	ref        string
+	suggestion string // closest surviving cursor-acp/<id>, "" if none
+}
+
+func (i modelRefIssue) String() string {
+	line := fmt.Sprintf("%s: %s", strings.Join(i.path, "."), i.ref)
+	if i.suggestion != "" {
+		line += " → " + i.suggestion
+	}
+	return line
+}
+
+// lintModelRefs walks model, small_model, agent.*.model and mode.*.model and
+// reports cursor-acp references that no longer resolve.
+func lintModelRefs(config *opencodeconfig.Config) []modelRefIssue {
+	provider := config.CursorACP()
+	surviving := provider.ModelIDs()
+
+	var issues []modelRefIssue
+	check := func(path []string, ref string) {
+		providerID, id, ok := opencodeconfig.SplitModelRef(ref)
+		if !ok || providerID != opencodeconfig.ProviderID || provider.HasModel(id) {
+			return
+		}
+		issue := modelRefIssue{path: path, ref: ref}
+		if closest := closestModelID(id, surviving); closest != "" {
+			issue.suggestion = opencodeconfig.ProviderID + "/" + closest
+		}
+		issues = append(issues, issue)
+	}
+
+	check([]string{"model"}, config.Model)
+	check([]string{"small_model"}, config.SmallModel)
+	for _, section := range []struct {
+		name    string
+		entries map[string]*opencodeconfig.Agent
+	}{{"agent", config.Agent}, {"mode", config.Mode}} {
+		names := make([]string, 0, len(section.entries))
+		for name := range section.entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if entry := section.entries[name]; entry != nil {
+				check([]string{section.name, name, "model"}, entry.Model)
+			}
+		}
+	}
+
+	return issues
+}
+
+// closestModelID picks the surviving id nearest to a removed one, e.g.
+// sonnet-4.5-thinking → sonnet-4.6-thinking. Falls back to auto when nothing
+// is reasonably close.
+func closestModelID(id string, surviving []string) string {
+	best := ""
+	bestDist := -1
+	for _, candidate := range surviving {
+		d := levenshtein(id, candidate)
+		if bestDist < 0 || d < bestDist ||
+			(d == bestDist && commonPrefixLen(id, candidate) > commonPrefixLen(id, best)) {
+			best, bestDist = candidate, d
+		}
+	}
+
+	if best != "" && bestDist <= len(id)/2 {
+		return best
+	}
+	for _, candidate := range surviving {
+		if candidate == "auto" {
+			return candidate
+		}
+	}
+	return best
+}
+
+func commonPrefixLen(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// lintModelRefsPrompt turns lint findings into a prompt offering to remap each
+// dangling reference to its suggestion. Returns nil when there is nothing to
+// report.
+func lintModelRefsPrompt(config *opencodeconfig.Config) *taskPrompt {
+	issues := lintModelRefs(config)
+	if len(issues) == 0 {
+		return nil
+	}
+
+	body := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		body = append(body, issue.String())
+	}
+
+	return &taskPrompt{
+		title: fmt.Sprintf("%d reference(s) to cursor-acp models that no longer exist", len(issues)),
+		body:  body,
+		options: []promptOption{
+			{key: "r", label: "Remap to suggested models", apply: func(m *model, _ string) error {
+				return remapModelRefs(m, issues)
+			}},
+			{key: "k", label: "Keep as is"},
+		},
+	}
+}
+
+func remapModelRefs(m *model, issues []modelRefIssue) error {
+	_ = backupConfigToDisk(m.configPath, m.currentTaskName())
+
+	config, err := opencodeconfig.Load(m.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	before := config.Clone()
+
+	for _, issue := range issues {
+		if issue.suggestion == "" {
+			continue
+		}
+		switch issue.path[0] {
+		case "model":
+			config.Model = issue.suggestion
+		case "small_model":
+			config.SmallModel = issue.suggestion
+		case "agent":
+			if entry := config.Agent[issue.path[1]]; entry != nil {
+				entry.Model = issue.suggestion
+			}
+		case "mode":
+			if entry := config.Mode[issue.path[1]]; entry != nil {
+				entry.Model = issue.suggestion
+			}
+		}
+	}
+
+	return saveTrackedConfig(m.configPath, before, config)
+}
@@ -0,0 +1,115 @@
+// cmd/installer/lint_test.go
+package main
+
+import (
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "abc", 0},
+		{"kitten", "sitting", 3},
+		{"sonnet-4.5", "sonnet-4.6", 1},
+		{"gpt-5", "gpt-5-codex", 6},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClosestModelID(t *testing.T) {
+	surviving := []string{"auto", "sonnet-4.6", "sonnet-4.6-thinking", "opus-4.6", "gpt-5.1"}
+	tests := []struct {
+		id, want string
+	}{
+		{"sonnet-4.5-thinking", "sonnet-4.6-thinking"},
+		{"sonnet-4.5", "sonnet-4.6"},
+		{"opus-4.5", "opus-4.6"},
+		{"gpt-5", "gpt-5.1"},
+		{"grok-code-fast-1", "auto"},
+	}
+	for _, tt := range tests {
+		if got := closestModelID(tt.id, surviving); got != tt.want {
+			t.Errorf("closestModelID(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+	if got := closestModelID("anything", nil); got != "" {
+		t.Errorf("no surviving models = %q, want empty", got)
+	}
+	if got := closestModelID("grok-code-fast-1", []string{"sonnet-4.6"}); got != "sonnet-4.6" {
+		t.Errorf("nothing close and no auto = %q, want the nearest", got)
+	}
+}
+
+const lintTestConfig = `{
+	"model": "cursor-acp/sonnet-4.5-thinking",
+	"small_model": "cursor-acp/auto",
+	"agent": {
+		"review": {"model": "cursor-acp/opus-4.5"},
+		"build": {"model": "anthropic/claude-sonnet-4-5"}
+	},
+	"mode": {"plan": {"model": "cursor-acp/gone-model-xyz"}},
+	"provider": {"cursor-acp": {"models": {"auto": {}, "sonnet-4.6-thinking": {}, "opus-4.6": {}}}}
+}`
+
+func TestLintModelRefs(t *testing.T) {
+	m := newTestModel(t)
+	writeTestConfig(t, m.configPath, lintTestConfig)
+
+	issues := lintModelRefs(loadTestConfig(t, m.configPath))
+	want := []string{
+		"model: cursor-acp/sonnet-4.5-thinking → cursor-acp/sonnet-4.6-thinking",
+		"agent.review.model: cursor-acp/opus-4.5 → cursor-acp/opus-4.6",
+		"mode.plan.model: cursor-acp/gone-model-xyz → cursor-acp/auto",
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("issues = %v", issues)
+	}
+	for i, issue := range issues {
+		if issue.String() != want[i] {
+			t.Errorf("issue %d = %q, want %q", i, issue.String(), want[i])
+		}
+	}
+}
+
+func TestRemapModelRefs(t *testing.T) {
+	m := newTestModel(t)
+	writeTestConfig(t, m.configPath, lintTestConfig)
+
+	if err := remapModelRefs(m, lintModelRefs(loadTestConfig(t, m.configPath))); err != nil {
+		t.Fatal(err)
+	}
+
+	config := loadTestConfig(t, m.configPath)
+	if config.Model != "cursor-acp/sonnet-4.6-thinking" || config.SmallModel != "cursor-acp/auto" {
+		t.Errorf("model = %q, small_model = %q", config.Model, config.SmallModel)
+	}
+	if got := config.Agent["review"].Model; got != "cursor-acp/opus-4.6" {
+		t.Errorf("agent.review.model = %q", got)
+	}
+	if got := config.Agent["build"].Model; got != "anthropic/claude-sonnet-4-5" {
+		t.Errorf("other providers' refs changed: %q", got)
+	}
+	if got := config.Mode["plan"].Model; got != "cursor-acp/auto" {
+		t.Errorf("mode.plan.model = %q", got)
+	}
+	if issues := lintModelRefs(config); len(issues) != 0 {
+		t.Errorf("issues after remap = %v", issues)
+	}
+
+	// The remap is recorded so uninstall can put the old refs back.
+	mf, err := loadInstallManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mf.Changes) != 1 || mf.Changes[0].Key != configKeyModel || priorString(mf.Changes[0].Prior) != "cursor-acp/sonnet-4.5-thinking" {
+		t.Errorf("manifest changes = %+v, want the model remap", mf.Changes)
+	}
+}
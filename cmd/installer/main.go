@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -20,7 +21,7 @@ const (
 	modeBuildFromSource
 )
 
-func newModel(debugMode, noRollback bool, logFile *os.File) model {
+func newModel(debugMode, noRollback, probeModels bool, logFile *os.File) model {
 	s := spinner.New()
 	s.Style = lipgloss.NewStyle().Foreground(Secondary)
 	s.Spinner = spinner.Dot
@@ -55,6 +56,11 @@ func newModel(debugMode, noRollback bool, logFile *os.File) model {
 		existingSetup: existingSetup,
 		backupFiles:   make(map[string][]byte),
 		npmTag:        npmTag,
+		probeModels:   probeModels,
+		disabled:      isPluginDisabled(),
+		linkMode:      linkModeSymlink,
+
+		installedVersion: installedNpmVersion(),
 
 		beams:  nil,
 		ticker: NewTypewriterTicker(),
@@ -129,19 +135,97 @@ func tickCmd() tea.Cmd {
 	})
 }
 
+// isValueFlag reports whether arg is name, as "--name value" or "--name=value".
+func isValueFlag(arg, name string) bool {
+	return arg == name || strings.HasPrefix(arg, name+"=")
+}
+
+// flagValue returns the value of the flag at args[*i], consuming the next
+// argument for the "--name value" form. A missing value exits.
+func flagValue(args []string, i *int) string {
+	arg := args[*i]
+	if _, value, ok := strings.Cut(arg, "="); ok {
+		return value
+	}
+	if *i+1 >= len(args) {
+		fmt.Fprintf(os.Stderr, "Error: %s requires a value\n", arg)
+		os.Exit(2)
+	}
+	*i++
+	return args[*i]
+}
+
 func main() {
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		os.Exit(runSubcommand(os.Args[1], os.Args[2:]))
+	}
+
 	debugMode := false
 	noRollback := false
+	probeModels := false
+	chooseDefaults := false
+	npmTag := ""
+	pinInstalled := false
+	runTests := false
+	snapshot := false
+	snapshotNodeModules := false
+	linkMode := ""
+	var source *installSource
 
-	for _, arg := range os.Args[1:] {
-		switch arg {
-		case "--debug", "-d":
+	args := os.Args[1:]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--debug" || arg == "-d":
 			debugMode = true
-		case "--no-rollback":
+		case arg == "--no-rollback":
 			noRollback = true
+		case arg == "--probe-models":
+			probeModels = true
+		case arg == "--choose-defaults":
+			chooseDefaults = true
+		case arg == "--run-tests":
+			runTests = true
+		case arg == "--snapshot":
+			snapshot = true
+		case arg == "--snapshot-node-modules":
+			snapshot = true
+			snapshotNodeModules = true
+		case arg == "--copy":
+			linkMode = linkModeCopy
+		case arg == "--relative-link":
+			linkMode = linkModeRelative
+		case arg == "--pin-installed":
+			pinInstalled = true
+		case isValueFlag(arg, "--plugin-version"):
+			npmTag = flagValue(args, &i)
+		case isValueFlag(arg, "--from-tarball"), isValueFlag(arg, "--from-dir"), isValueFlag(arg, "--from-git"):
+			name, _, _ := strings.Cut(strings.TrimPrefix(arg, "--from-"), "=")
+			src, err := parseInstallSource(name, flagValue(args, &i))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(2)
+			}
+			if source != nil {
+				fmt.Fprintln(os.Stderr, "Error: only one --from-* source may be given")
+				os.Exit(2)
+			}
+			source = src
+		case arg == "--help" || arg == "-h":
+			printUsage(os.Stdout)
+			return
 		}
 	}
 
+	if npmTag != "" {
+		tag, err := parseNpmTag(npmTag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		npmTag = tag
+	}
+
 	logFile, err := os.CreateTemp("", "opencode-cursor-installer-*.log")
 	if err != nil {
 		logFile = nil
@@ -153,7 +237,28 @@ func main() {
 		logFile.WriteString(fmt.Sprintf("Debug Mode: %v\n\n", debugMode))
 	}
 
-	m := newModel(debugMode, noRollback, logFile)
+	m := newModel(debugMode, noRollback, probeModels, logFile)
+	m.runTests = runTests
+	m.chooseDefaults = chooseDefaults
+	m.snapshot = snapshot
+	m.snapshotNodeModules = snapshotNodeModules
+	if linkMode != "" {
+		m.linkMode = linkMode
+	}
+	if npmTag != "" {
+		m.npmTag = npmTag
+	}
+	if pinInstalled {
+		if m.installedVersion == "" {
+			fmt.Fprintln(os.Stderr, "Error: --pin-installed: "+npmPackage+" is not installed globally")
+			os.Exit(2)
+		}
+		m.npmTag = m.installedVersion
+	}
+	if source != nil {
+		m.installSource = source
+		m.mode = modeBuildFromSource
+	}
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	globalProgram = p
 
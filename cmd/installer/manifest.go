@@ -0,0 +1,235 @@
+// cmd/installer/manifest.go
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const manifestVersion = 1
+
+// installManifest records how the plugin was installed, in the state
+// directory, so later runs know where the active plugin came from.
+type installManifest struct {
+	Version     int            `json:"version"`
+	InstalledAt time.Time      `json:"installedAt"`
+	Source      manifestSource `json:"source"`
+	// PluginEntry is the plugin-entry.js that cursor-acp.js points at;
+	// empty when OpenCode loads the npm package from the plugin array.
+	PluginEntry string `json:"pluginEntry,omitempty"`
+	// LinkMode is how cursor-acp.js refers to PluginEntry: symlink,
+	// relative or copy.
+	LinkMode string `json:"linkMode,omitempty"`
+	// Changes is everything install created or changed, oldest first, so
+	// uninstall can revert exactly that.
+	Changes []manifestChange `json:"changes,omitempty"`
+}
+
+// Kinds of manifestChange.
+const (
+	changeFile    = "file"
+	changeDir     = "dir"
+	changePackage = "package"
+	changeConfig  = "config"
+)
+
+// manifestChange is one thing install created or changed.
+type manifestChange struct {
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+	// Key is the package name for packages, and for config changes the
+	// setting: provider.cursor-acp, model, small_model or plugin.
+	Key string `json:"key,omitempty"`
+	// Value is what was written where it is needed to find it again: the
+	// symlink target or the plugin spec.
+	Value string `json:"value,omitempty"`
+	// Hash is the sha256 of what was written, to spot later edits.
+	Hash string `json:"hash,omitempty"`
+	// Existed marks items present before the first install; uninstall
+	// leaves them alone or puts Prior back.
+	Existed bool            `json:"existed,omitempty"`
+	Prior   json.RawMessage `json:"prior,omitempty"`
+	// Via is how a package was installed: "bun" (in Path) or "npm -g".
+	Via string `json:"via,omitempty"`
+}
+
+// manifestSource is where the installed plugin came from.
+type manifestSource struct {
+	Kind     string `json:"kind"` // npm, build, tarball, dir, git, plugin-array
+	Location string `json:"location,omitempty"`
+	Ref      string `json:"ref,omitempty"`
+	Commit   string `json:"commit,omitempty"`
+	Version  string `json:"version,omitempty"`
+}
+
+func manifestPath() (string, error) {
+	stateDir, err := getStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "install-manifest.json"), nil
+}
+
+// loadInstallManifest reads the manifest; a missing file is reported as an
+// error satisfying os.IsNotExist.
+func loadInstallManifest() (*installManifest, error) {
+	path, err := manifestPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var mf installManifest
+	if err := json.Unmarshal(data, &mf); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &mf, nil
+}
+
+func (mf *installManifest) save() error {
+	stateDir, err := ensureStateDir()
+	if err != nil {
+		return err
+	}
+	mf.Version = manifestVersion
+	data, err := json.MarshalIndent(mf, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(stateDir, "install-manifest.json"), data, 0644)
+}
+
+// recordInstallSource records the plugin just installed. Changes from
+// earlier installs are kept: what existed before the first one is what
+// uninstall restores.
+func recordInstallSource(source manifestSource, pluginEntry string) error {
+	mf, err := loadInstallManifest()
+	if err != nil {
+		mf = &installManifest{}
+	}
+	mf.InstalledAt = time.Now().UTC()
+	mf.Source = source
+	mf.PluginEntry = pluginEntry
+	mf.LinkMode = "" // set by createSymlink when there is a plugin file
+	if err := mf.save(); err != nil {
+		return fmt.Errorf("failed to write install manifest: %w", err)
+	}
+	return nil
+}
+
+// recordLinkMode notes how cursor-acp.js was created in the manifest the
+// install task wrote.
+func recordLinkMode(mode string) error {
+	mf, err := loadInstallManifest()
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	mf.LinkMode = mode
+	if err := mf.save(); err != nil {
+		return fmt.Errorf("failed to write install manifest: %w", err)
+	}
+	return nil
+}
+
+// addChange records c, merging it into an earlier record of the same item.
+// The earlier Existed and Prior win; they describe the state before the
+// first install.
+func (mf *installManifest) addChange(c manifestChange) {
+	for i := range mf.Changes {
+		prev := &mf.Changes[i]
+		if prev.Kind != c.Kind || prev.Path != c.Path || prev.Key != c.Key {
+			continue
+		}
+		// Plugin entries are tracked per spec: a retag continues the
+		// record of the spec it replaced.
+		if c.Key == configKeyPlugin && prev.Value != c.Value && prev.Value != priorString(c.Prior) {
+			continue
+		}
+		prev.Value = c.Value
+		prev.Hash = c.Hash
+		return
+	}
+	mf.Changes = append(mf.Changes, c)
+}
+
+// recordChanges adds changes to the manifest, creating it if needed.
+func recordChanges(changes ...manifestChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	mf, err := loadInstallManifest()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if mf == nil {
+		mf = &installManifest{InstalledAt: time.Now().UTC()}
+	}
+	for _, c := range changes {
+		mf.addChange(c)
+	}
+	if err := mf.save(); err != nil {
+		return fmt.Errorf("failed to write install manifest: %w", err)
+	}
+	return nil
+}
+
+func removeInstallManifest() error {
+	path, err := manifestPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// priorString decodes a Prior holding a JSON string, or returns "".
+func priorString(raw json.RawMessage) string {
+	var s string
+	if len(raw) == 0 || json.Unmarshal(raw, &s) != nil {
+		return ""
+	}
+	return s
+}
+
+// pathExists reports whether anything, even a dangling symlink, is at path.
+func pathExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// pluginFileChange describes the plugin file as it is now, for recording
+// after it was (re)created. existedBefore is whether anything was there;
+// priorTarget the symlink it replaced, if any.
+func pluginFileChange(path string, existedBefore bool, priorTarget string) (manifestChange, error) {
+	c := manifestChange{Kind: changeFile, Path: path, Existed: existedBefore}
+	if priorTarget != "" {
+		c.Prior, _ = json.Marshal(priorTarget)
+	}
+	if target, err := os.Readlink(path); err == nil {
+		c.Value = target
+		return c, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	c.Hash = hashBytes(data)
+	return c, nil
+}
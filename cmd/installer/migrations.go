@@ -0,0 +1,310 @@
+// cmd/installer/migrations.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+// migration cleans up one thing an older plugin or installer version left.
+// detect lists what it found (nothing means there is nothing to do); apply
+// removes or converts it. A confirm migration may remove something still in
+// use, so install only reports it; it runs when the user agrees, from
+// repair, or with doctor --fix.
+type migration struct {
+	version     int
+	id          string
+	description string
+	confirm     bool
+	detect      func(m *model) []string
+	apply       func(m *model) error
+}
+
+// migrations run in order at the start of install and repair. To retire
+// something, append an entry with the next version; never renumber.
+var migrations = []migration{
+	{
+		version:     1,
+		id:          "legacy-auth-plugin",
+		description: "Remove the retired " + opencodeconfig.LegacyAuthPlugin + " plugin",
+		detect:      detectLegacyAuthPlugin,
+		apply:       removeOldPlugin,
+	},
+	{
+		version:     2,
+		id:          "node-modules-link",
+		description: "Remove the node_modules/cursor-acp link older installers made",
+		detect:      existingPaths(oldNodeModulesLink),
+		apply:       removeOldNodeModulesLink,
+	},
+	{
+		version:     3,
+		id:          "acp-sdk",
+		description: "Remove @agentclientprotocol/sdk, which the plugin no longer uses",
+		confirm:     true, // other plugins in the config dir may depend on it
+		detect:      existingPaths(acpSdkPath),
+		apply:       removeAcpSdk,
+	},
+	{
+		version:     4,
+		id:          "config-bak-files",
+		description: "Move opencode.json.bak.* files into the backups directory",
+		detect:      func(m *model) []string { return legacyBackups(m.configPath) },
+		apply: func(m *model) error {
+			_, err := importLegacyBackups(m.configPath)
+			return err
+		},
+	},
+}
+
+// migrationState records which migrations have run, in the state dir.
+type migrationState struct {
+	Version int                `json:"version"`
+	Applied []appliedMigration `json:"applied,omitempty"`
+}
+
+type appliedMigration struct {
+	ID        string    `json:"id"`
+	Version   int       `json:"version"`
+	AppliedAt time.Time `json:"appliedAt"`
+	Found     []string  `json:"found"`
+}
+
+// pendingMigration is a migration whose detect found something.
+type pendingMigration struct {
+	migration
+	found []string
+}
+
+func oldNodeModulesLink() (string, error) {
+	dir, err := opencodeConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "node_modules", "cursor-acp"), nil
+}
+
+func acpSdkPath() (string, error) {
+	dir, err := opencodeConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "node_modules", "@agentclientprotocol", "sdk"), nil
+}
+
+// existingPaths makes a detect step from a path that should not exist.
+// Dangling links count.
+func existingPaths(path func() (string, error)) func(*model) []string {
+	return func(*model) []string {
+		p, err := path()
+		if err != nil {
+			return nil
+		}
+		if _, err := os.Lstat(p); err != nil {
+			return nil
+		}
+		return []string{p}
+	}
+}
+
+func detectLegacyAuthPlugin(m *model) []string {
+	var found []string
+	if config, err := opencodeconfig.Load(m.configPath); err == nil && config.HasPlugin(opencodeconfig.PluginEntry.IsLegacyAuth) {
+		found = append(found, m.configPath+": "+opencodeconfig.LegacyAuthPlugin+" plugin entry")
+	}
+	if cacheDir, err := getOpenCodeModulesDir(); err == nil {
+		if path := filepath.Join(cacheDir, opencodeconfig.LegacyAuthPlugin); pathExists(path) {
+			found = append(found, path)
+		}
+	}
+	return found
+}
+
+func removeOldNodeModulesLink(m *model) error {
+	path, err := oldNodeModulesLink()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
+
+func migrationStatePath() (string, error) {
+	stateDir, err := getStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "migrations.json"), nil
+}
+
+func loadMigrationState() (*migrationState, error) {
+	path, err := migrationStatePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &migrationState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state migrationState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+func saveMigrationState(state *migrationState) error {
+	if _, err := ensureStateDir(); err != nil {
+		return err
+	}
+	path, err := migrationStatePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// detectMigrations returns the migrations that have something to clean up.
+// With all unset, those already recorded as run are skipped.
+func detectMigrations(m *model, all bool) []pendingMigration {
+	done := 0
+	if !all {
+		if state, err := loadMigrationState(); err == nil {
+			done = state.Version
+		}
+	}
+	var pending []pendingMigration
+	for _, mg := range migrations {
+		if mg.version <= done {
+			continue
+		}
+		if found := mg.detect(m); len(found) > 0 {
+			pending = append(pending, pendingMigration{migration: mg, found: found})
+		}
+	}
+	return pending
+}
+
+// runMigrations applies the pending migrations and records the version
+// reached. Unless confirmed, confirm migrations are held back and returned
+// instead. A failed or held migration stops the version there so it's
+// detected again next time.
+func runMigrations(m *model, confirmed bool) (applied, held []pendingMigration, err error) {
+	state, err := loadMigrationState()
+	if err != nil {
+		return nil, nil, err
+	}
+	pending := detectMigrations(m, false)
+
+	var failed error
+	reached := latestMigrationVersion()
+	for _, p := range pending {
+		if p.confirm && !confirmed {
+			held = append(held, p)
+			reached = min(reached, p.version-1)
+			continue
+		}
+		if err := p.apply(m); err != nil {
+			failed = fmt.Errorf("migration %s: %w", p.id, err)
+			reached = min(reached, p.version-1)
+			break
+		}
+		applied = append(applied, p)
+		state.Applied = append(state.Applied, appliedMigration{ID: p.id, Version: p.version, AppliedAt: time.Now().UTC(), Found: p.found})
+	}
+	if reached > state.Version {
+		state.Version = reached
+	}
+	if err := saveMigrationState(state); err != nil && failed == nil {
+		failed = err
+	}
+	return applied, held, failed
+}
+
+func latestMigrationVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].version
+}
+
+// migrationsTask runs the pending migrations. Repair passes confirmed, as
+// the user has already agreed to the list it showed.
+func migrationsTask(confirmed bool) installTask {
+	execute := migrateLegacyInstall
+	if confirmed {
+		execute = func(m *model) error {
+			_, _, err := runMigrations(m, true)
+			return err
+		}
+	}
+	return installTask{name: "Migrate legacy install", description: "Cleaning up what older versions left", execute: execute, optional: true, status: statusPending}
+}
+
+// migrateLegacyInstall runs the pending migrations and, when any ran or were
+// held back for confirmation, shows what they found.
+func migrateLegacyInstall(m *model) error {
+	applied, held, err := runMigrations(m, false)
+	if m.logFile != nil {
+		for _, p := range applied {
+			m.logFile.WriteString(fmt.Sprintf("Migration %d %s: %v\n", p.version, p.id, p.found))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 && len(held) == 0 {
+		return nil
+	}
+
+	var body []string
+	list := func(mark string, pending []pendingMigration) {
+		for _, p := range pending {
+			body = append(body, mark+" "+p.description)
+			for _, f := range p.found {
+				body = append(body, "    "+f)
+			}
+		}
+	}
+	list(checkMark.String(), applied)
+	if len(held) == 0 {
+		m.prompt = &taskPrompt{
+			title:   fmt.Sprintf("Migrated %d legacy artifact(s)", len(applied)),
+			body:    body,
+			options: []promptOption{{key: "enter", label: "Continue"}},
+		}
+		return nil
+	}
+
+	if len(body) > 0 {
+		body = append(body, "")
+	}
+	body = append(body, "Not removed without asking, as something else may still use it:")
+	list(skipMark.String(), held)
+	m.prompt = &taskPrompt{
+		title: fmt.Sprintf("%d legacy artifact(s) need confirmation", len(held)),
+		body:  body,
+		options: []promptOption{
+			{key: "a", label: "Remove them too", apply: func(m *model, _ string) error {
+				_, _, err := runMigrations(m, true)
+				return err
+			}},
+			{key: "enter", label: "Keep them (repair or doctor --fix removes them later)"},
+		},
+	}
+	return nil
+}
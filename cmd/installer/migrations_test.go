@@ -0,0 +1,134 @@
+// cmd/installer/migrations_test.go
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+func TestRunMigrations(t *testing.T) {
+	m := newTestModel(t)
+	writeTestConfig(t, m.configPath, `{"plugin": ["cursor-acp", "`+opencodeconfig.LegacyAuthPlugin+`@1.0.0"]}`)
+	old, _ := oldNodeModulesLink()
+	if err := os.MkdirAll(filepath.Dir(old), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/gone", old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(m.configPath+".bak.1700000000", []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, held, err := runMigrations(m, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(held) != 0 {
+		t.Errorf("held = %v", held)
+	}
+	var ids []string
+	for _, p := range applied {
+		ids = append(ids, p.id)
+	}
+	if len(ids) != 3 || ids[0] != "legacy-auth-plugin" || ids[1] != "node-modules-link" || ids[2] != "config-bak-files" {
+		t.Fatalf("applied = %v", ids)
+	}
+	if loadTestConfig(t, m.configPath).HasPlugin(opencodeconfig.PluginEntry.IsLegacyAuth) {
+		t.Error("legacy auth entry still in the plugin array")
+	}
+	if _, err := os.Lstat(old); !os.IsNotExist(err) {
+		t.Errorf("old link still there: %v", err)
+	}
+	if len(legacyBackups(m.configPath)) != 0 {
+		t.Error(".bak files not moved")
+	}
+
+	state, err := loadMigrationState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Version != latestMigrationVersion() || len(state.Applied) != 3 {
+		t.Errorf("state = %+v", state)
+	}
+
+	// Recorded migrations don't run again, but doctor still sees artifacts
+	// that came back.
+	if err := os.Symlink("/gone", old); err != nil {
+		t.Fatal(err)
+	}
+	if pending := detectMigrations(m, false); len(pending) != 0 {
+		t.Errorf("pending after run = %v", pending)
+	}
+	if pending := detectMigrations(m, true); len(pending) != 1 || pending[0].id != "node-modules-link" {
+		t.Errorf("all = %v", pending)
+	}
+}
+
+func TestRunMigrationsStopsAtFailure(t *testing.T) {
+	m := newTestModel(t)
+	saved := migrations
+	defer func() { migrations = saved }()
+
+	found := func(*model) []string { return []string{"artifact"} }
+	ran := 0
+	migrations = []migration{
+		{version: 1, id: "one", detect: found, apply: func(*model) error { ran++; return nil }},
+		{version: 2, id: "two", detect: found, apply: func(*model) error { return errors.New("boom") }},
+		{version: 3, id: "three", detect: found, apply: func(*model) error { ran++; return nil }},
+	}
+
+	applied, _, err := runMigrations(m, false)
+	if err == nil || len(applied) != 1 || ran != 1 {
+		t.Fatalf("applied = %v, err = %v, ran = %d", applied, err, ran)
+	}
+	state, _ := loadMigrationState()
+	if state.Version != 1 {
+		t.Errorf("version = %d, want 1", state.Version)
+	}
+	if pending := detectMigrations(m, false); len(pending) != 2 || pending[0].id != "two" {
+		t.Errorf("pending = %v", pending)
+	}
+}
+
+func TestRunMigrationsHoldsConfirmMigrations(t *testing.T) {
+	m := newTestModel(t)
+	sdk, _ := acpSdkPath()
+	if err := os.MkdirAll(sdk, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(m.configPath+".bak.1700000000", []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrateLegacyInstall(m); err != nil {
+		t.Fatal(err)
+	}
+	if !pathExists(sdk) {
+		t.Fatal("acp-sdk removed without confirmation")
+	}
+	if len(legacyBackups(m.configPath)) != 0 {
+		t.Error("unconfirmed migration held back the others")
+	}
+	if m.prompt == nil || len(m.prompt.options) != 2 || m.prompt.options[0].key != "a" {
+		t.Fatalf("prompt = %+v, want a confirmation", m.prompt)
+	}
+	if pending := detectMigrations(m, false); len(pending) != 1 || pending[0].id != "acp-sdk" {
+		t.Errorf("pending = %v, want acp-sdk reported again", pending)
+	}
+
+	if err := m.prompt.options[0].apply(m, ""); err != nil {
+		t.Fatal(err)
+	}
+	if pathExists(sdk) {
+		t.Error("acp-sdk still there after confirming")
+	}
+	state, _ := loadMigrationState()
+	if state.Version != latestMigrationVersion() {
+		t.Errorf("version = %d, want %d", state.Version, latestMigrationVersion())
+	}
+}
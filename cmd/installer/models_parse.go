@@ -0,0 +1,302 @@
+// cmd/installer/models_parse.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// modelsParser understands one known layout of `cursor-agent models` output.
+// When a cursor-agent release changes the layout, add a new parser (or bump
+// the version of an existing one) instead of loosening an existing regex.
+type modelsParser struct {
+	name    string
+	version int
+	// parse returns the models it recognised (id → display name) and the
+	// non-noise lines it could not make sense of.
+	parse func(clean string) (map[string]string, []string)
+}
+
+func (p modelsParser) id() string {
+	return fmt.Sprintf("%s/v%d", p.name, p.version)
+}
+
+// modelsParsers lists every known layout. All of them run against the output
+// and the one that rejects the fewest lines wins; on a tie the one that found
+// more models does, then the earlier entry, so JSON comes first because it
+// is the only layout with a contract.
+var modelsParsers = []modelsParser{
+	{name: "json", version: 1, parse: parseModelsJSON},
+	{name: "dash-list", version: 1, parse: parseModelsDashList},
+	{name: "bullet-list", version: 1, parse: parseModelsBulletList},
+	{name: "table", version: 1, parse: parseModelsTable},
+	{name: "columns", version: 1, parse: parseModelsColumns},
+}
+
+// modelsParseAttempt records how a single parser fared against an output.
+type modelsParseAttempt struct {
+	parser   string
+	rejected []string
+}
+
+// modelsParseReport is returned when no parser recognised the output.
+type modelsParseReport struct {
+	lines    int
+	attempts []modelsParseAttempt
+}
+
+func (r *modelsParseReport) Error() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("no parser matched %d lines; tried ", r.lines))
+	for i, a := range r.attempts {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(a.parser)
+		if len(a.rejected) == 0 {
+			b.WriteString(" (no candidate lines)")
+			continue
+		}
+		b.WriteString(fmt.Sprintf(" (rejected %d: %s)", len(a.rejected), quoteRejectedLines(a.rejected, 2)))
+	}
+	return b.String()
+}
+
+func quoteRejectedLines(lines []string, max int) string {
+	quoted := make([]string, 0, max+1)
+	for i, line := range lines {
+		if i == max {
+			quoted = append(quoted, fmt.Sprintf("+%d more", len(lines)-max))
+			break
+		}
+		if len(line) > 60 {
+			line = line[:60] + "..."
+		}
+		quoted = append(quoted, fmt.Sprintf("%q", line))
+	}
+	return strings.Join(quoted, " ")
+}
+
+var (
+	ansiRegex         = regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
+	modelIDRegex      = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+	modelStatusRegex  = regexp.MustCompile(`(?:\s*\((?:current|default)\))+\s*$`)
+	dashListRegex     = regexp.MustCompile(`^(?:[*>]\s+)?([a-zA-Z0-9._-]+)\s+[-–—:]\s+(.+?)\s*$`)
+	bulletListRegex   = regexp.MustCompile(`^[*•>]\s+([a-zA-Z0-9._-]+)(?:\s+[-–—:]\s+(.+?))?\s*$`)
+	columnsSplitRegex = regexp.MustCompile(`\s{2,}|\t+`)
+	tableBorderRegex  = regexp.MustCompile(`^[\s─━═┌┐└┘├┤┬┴┼╭╮╰╯│|+=:-]+$`)
+)
+
+// stripANSI removes the colour and style escapes cursor-agent emits on a TTY.
+func stripANSI(s string) string {
+	return ansiRegex.ReplaceAllString(s, "")
+}
+
+// parseCursorModelsOutput runs every registered parser against ANSI-stripped
+// cursor-agent output and returns the best result. On failure the error is a
+// *modelsParseReport naming each parser and the lines it rejected.
+func parseCursorModelsOutput(clean string) (map[string]string, error) {
+	models, _, err := parseCursorModelsWith(modelsParsers, clean)
+	return models, err
+}
+
+// parseCursorModelsWith is parseCursorModelsOutput with an explicit registry;
+// it also reports which parser produced the result.
+func parseCursorModelsWith(parsers []modelsParser, clean string) (map[string]string, string, error) {
+	report := &modelsParseReport{lines: len(strings.Split(clean, "\n"))}
+	var best map[string]string
+	bestParser := ""
+	bestRejected := 0
+
+	for _, p := range parsers {
+		models, rejected := p.parse(clean)
+		report.attempts = append(report.attempts, modelsParseAttempt{
+			parser:   p.id(),
+			rejected: rejected,
+		})
+		if len(models) == 0 {
+			continue
+		}
+		if best == nil || len(rejected) < bestRejected || (len(rejected) == bestRejected && len(models) > len(best)) {
+			best, bestParser, bestRejected = models, p.id(), len(rejected)
+		}
+	}
+
+	if best == nil {
+		return nil, "", report
+	}
+	return best, bestParser, nil
+}
+
+// isModelsNoiseLine reports lines every text layout prints around the list.
+func isModelsNoiseLine(line string) bool {
+	return line == "" ||
+		strings.HasPrefix(line, "Available") ||
+		strings.HasPrefix(line, "Tip:") ||
+		strings.HasPrefix(line, "Loading")
+}
+
+// cleanModelName strips the "(current)" / "(default)" markers cursor-agent
+// appends to the active model.
+func cleanModelName(name string) string {
+	return strings.TrimSpace(modelStatusRegex.ReplaceAllString(name, ""))
+}
+
+func addParsedModel(models map[string]string, id, name string) bool {
+	if !modelIDRegex.MatchString(id) {
+		return false
+	}
+	name = cleanModelName(name)
+	if name == "" {
+		name = id
+	}
+	if _, seen := models[id]; !seen {
+		models[id] = name
+	}
+	return true
+}
+
+// parseModelsJSON accepts the machine-readable layouts: a bare array of
+// {id, name} objects, or an object wrapping that array under "models".
+func parseModelsJSON(clean string) (map[string]string, []string) {
+	models := make(map[string]string)
+	trimmed := strings.TrimSpace(clean)
+	if !strings.HasPrefix(trimmed, "[") && !strings.HasPrefix(trimmed, "{") {
+		if trimmed == "" {
+			return models, nil
+		}
+		return models, []string{strings.SplitN(trimmed, "\n", 2)[0]}
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal([]byte(trimmed), &raw); err != nil {
+		return models, []string{fmt.Sprintf("invalid JSON: %v", err)}
+	}
+	if wrapper, ok := raw.(map[string]interface{}); ok {
+		raw = wrapper["models"]
+	}
+	entries, ok := raw.([]interface{})
+	if !ok {
+		return models, []string{"JSON has no models array"}
+	}
+
+	var rejected []string
+	for _, e := range entries {
+		entry, _ := e.(map[string]interface{})
+		id, _ := entry["id"].(string)
+		name, _ := entry["name"].(string)
+		if name == "" {
+			name, _ = entry["displayName"].(string)
+		}
+		if !addParsedModel(models, id, name) {
+			encoded, _ := json.Marshal(e)
+			rejected = append(rejected, string(encoded))
+		}
+	}
+	return models, rejected
+}
+
+// parseModelsDashList handles the classic "id - Display Name (current)" list,
+// including releases that prefix the current model with "*" or ">".
+func parseModelsDashList(clean string) (map[string]string, []string) {
+	models := make(map[string]string)
+	var rejected []string
+	for _, line := range strings.Split(clean, "\n") {
+		line = strings.TrimSpace(line)
+		if isModelsNoiseLine(line) {
+			continue
+		}
+		matches := dashListRegex.FindStringSubmatch(line)
+		if matches == nil || !addParsedModel(models, matches[1], matches[2]) {
+			rejected = append(rejected, line)
+		}
+	}
+	return models, rejected
+}
+
+// parseModelsBulletList handles "• id - Name" and "* id" style lists where
+// every entry is bulleted and the display name may be omitted.
+func parseModelsBulletList(clean string) (map[string]string, []string) {
+	models := make(map[string]string)
+	var rejected []string
+	for _, line := range strings.Split(clean, "\n") {
+		line = strings.TrimSpace(line)
+		if isModelsNoiseLine(line) {
+			continue
+		}
+		matches := bulletListRegex.FindStringSubmatch(line)
+		if matches == nil || !addParsedModel(models, matches[1], matches[2]) {
+			rejected = append(rejected, line)
+		}
+	}
+	return models, rejected
+}
+
+// parseModelsTable handles box-drawing and pipe tables with an id column
+// followed by an optional name column.
+func parseModelsTable(clean string) (map[string]string, []string) {
+	models := make(map[string]string)
+	var rejected []string
+	for _, line := range strings.Split(clean, "\n") {
+		line = strings.TrimSpace(line)
+		if isModelsNoiseLine(line) || tableBorderRegex.MatchString(line) {
+			continue
+		}
+		if !strings.ContainsAny(line, "│|") {
+			rejected = append(rejected, line)
+			continue
+		}
+
+		var cells []string
+		for _, cell := range strings.FieldsFunc(line, func(r rune) bool { return r == '│' || r == '|' }) {
+			if cell = strings.TrimSpace(cell); cell != "" {
+				cells = append(cells, cell)
+			}
+		}
+		if len(cells) == 0 || isModelsHeaderCell(cells[0]) {
+			continue
+		}
+		name := ""
+		if len(cells) > 1 {
+			name = cells[1]
+		}
+		if !addParsedModel(models, cells[0], name) {
+			rejected = append(rejected, line)
+		}
+	}
+	return models, rejected
+}
+
+// parseModelsColumns handles whitespace-aligned "ID  NAME" listings.
+func parseModelsColumns(clean string) (map[string]string, []string) {
+	models := make(map[string]string)
+	var rejected []string
+	for _, line := range strings.Split(clean, "\n") {
+		line = strings.TrimSpace(line)
+		if isModelsNoiseLine(line) {
+			continue
+		}
+		fields := columnsSplitRegex.Split(line, -1)
+		if len(fields) < 2 {
+			rejected = append(rejected, line)
+			continue
+		}
+		if isModelsHeaderCell(fields[0]) {
+			continue
+		}
+		if !addParsedModel(models, fields[0], fields[1]) {
+			rejected = append(rejected, line)
+		}
+	}
+	return models, rejected
+}
+
+func isModelsHeaderCell(cell string) bool {
+	switch strings.ToLower(cell) {
+	case "id", "model", "model id", "models":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+// cmd/installer/models_parse_test.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// modelsFixture is the expected result stored next to each output in
+// testdata/models: <name>.txt holds the raw output, <name>.json this. Source
+// names the cursor-agent version it was captured from (see the README there).
+type modelsFixture struct {
+	Source string            `json:"source"`
+	Parser string            `json:"parser"`
+	Models map[string]string `json:"models"`
+}
+
+func TestParseCursorModelsFixtures(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "models", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no fixtures found in testdata/models")
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".txt")
+		t.Run(name, func(t *testing.T) {
+			raw, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedData, err := os.ReadFile(strings.TrimSuffix(input, ".txt") + ".json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var expected modelsFixture
+			if err := json.Unmarshal(expectedData, &expected); err != nil {
+				t.Fatal(err)
+			}
+			if expected.Source == "" {
+				t.Fatal("fixture does not say where its output came from")
+			}
+
+			models, parser, err := parseCursorModelsWith(modelsParsers, stripANSI(string(raw)))
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if parser != expected.Parser {
+				t.Errorf("parser = %s, want %s", parser, expected.Parser)
+			}
+
+			if !reflect.DeepEqual(models, expected.Models) {
+				t.Errorf("models = %v, want %v", models, expected.Models)
+			}
+		})
+	}
+}
+
+func TestParseCursorModelsReportsRejectedLines(t *testing.T) {
+	_, err := parseCursorModelsOutput("Error: not logged in\nRun cursor-agent login first\n")
+	var report *modelsParseReport
+	if !errors.As(err, &report) {
+		t.Fatalf("expected *modelsParseReport, got %v", err)
+	}
+	if len(report.attempts) != len(modelsParsers) {
+		t.Fatalf("attempts = %d, want %d", len(report.attempts), len(modelsParsers))
+	}
+	for _, a := range report.attempts {
+		if len(a.rejected) == 0 {
+			t.Errorf("%s rejected no lines", a.parser)
+		}
+	}
+	if !strings.Contains(err.Error(), "dash-list/v1") || !strings.Contains(err.Error(), "Error: not logged in") {
+		t.Errorf("report does not name parsers and lines: %s", err)
+	}
+}
+
+func TestParseCursorModelsPrefersMoreModelsOnTie(t *testing.T) {
+	parsers := []modelsParser{
+		{name: "few", version: 1, parse: func(string) (map[string]string, []string) {
+			return map[string]string{"auto": "Auto"}, []string{"junk"}
+		}},
+		{name: "many", version: 1, parse: func(string) (map[string]string, []string) {
+			return map[string]string{"auto": "Auto", "gpt-5": "GPT-5"}, []string{"junk"}
+		}},
+		{name: "same", version: 1, parse: func(string) (map[string]string, []string) {
+			return map[string]string{"auto": "Auto", "sonnet-4": "Sonnet 4"}, []string{"junk"}
+		}},
+	}
+
+	models, parser, err := parseCursorModelsWith(parsers, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parser != "many/v1" || len(models) != 2 {
+		t.Errorf("parser = %s, models = %v, want many/v1", parser, models)
+	}
+}
+
+func FuzzParseCursorModelsOutput(f *testing.F) {
+	inputs, _ := filepath.Glob(filepath.Join("testdata", "models", "*.txt"))
+	for _, input := range inputs {
+		if raw, err := os.ReadFile(input); err == nil {
+			f.Add(string(raw))
+		}
+	}
+	f.Add("")
+	f.Add("{")
+	f.Add("│ │")
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		models, err := parseCursorModelsOutput(stripANSI(raw))
+		if err != nil {
+			if models != nil {
+				t.Fatalf("models returned alongside error: %v", err)
+			}
+			return
+		}
+		if len(models) == 0 {
+			t.Fatal("success with no models")
+		}
+		for id, name := range models {
+			if !modelIDRegex.MatchString(id) {
+				t.Fatalf("invalid model id %q", id)
+			}
+			if strings.TrimSpace(name) == "" {
+				t.Fatalf("model %q has empty name", id)
+			}
+		}
+	})
+}
@@ -0,0 +1,184 @@
+// cmd/installer/plugins.go
+package main
+
+import (
+	"fmt"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+// preferredPluginSpec is the plugin entry the current install mode sets up:
+// the local symlink for source builds and explicit install sources, the npm
+// package otherwise.
+func preferredPluginSpec(m *model) string {
+	if m.mode == modeBuildFromSource || m.installSource != nil {
+		return opencodeconfig.ProviderID
+	}
+	return npmPackage + "@" + m.npmTag
+}
+
+// describePluginEntry explains where a cursor-acp variant loads the plugin from.
+func describePluginEntry(e opencodeconfig.PluginEntry) string {
+	switch e.Kind() {
+	case opencodeconfig.PluginLocal:
+		return fmt.Sprintf("%q — local build via the plugin/cursor-acp.js symlink", e.Spec)
+	case opencodeconfig.PluginNPM:
+		if _, version := e.Package(); version != "" && version != "latest" {
+			return fmt.Sprintf("%q — npm package pinned to %s", e.Spec, version)
+		}
+		return fmt.Sprintf("%q — npm package tracking latest", e.Spec)
+	case opencodeconfig.PluginLegacy:
+		return fmt.Sprintf("%q — legacy auth plugin, conflicts with cursor-acp", e.Spec)
+	}
+	return fmt.Sprintf("%q", e.Spec)
+}
+
+// normalizePluginEntries is the "Normalize plugin entries" task. OpenCode loads
+// every entry in the plugin array, so several cursor-acp variants register
+// the provider more than once. When that happens, or the only entry doesn't
+// match this install mode, it asks which entry to keep.
+func normalizePluginEntries(m *model) error {
+	config, err := opencodeconfig.Load(m.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	preferred := preferredPluginSpec(m)
+	variants := config.CursorACPVariants()
+	if len(variants) == 0 {
+		return nil
+	}
+	if len(variants) == 1 {
+		entry := config.Plugin[variants[0]]
+		if entry.Kind() == opencodeconfig.Plugin(preferred).Kind() {
+			return nil
+		}
+	}
+
+	body := []string{"OpenCode loads every plugin entry; keep exactly one of:"}
+	var choices []string
+	seen := make(map[string]bool)
+	for _, i := range variants {
+		entry := config.Plugin[i]
+		body = append(body, "  "+describePluginEntry(entry))
+		if entry.Kind() != opencodeconfig.PluginLegacy && !seen[entry.Spec] {
+			choices = append(choices, entry.Spec)
+			seen[entry.Spec] = true
+		}
+	}
+	if !seen[preferred] {
+		choices = append(choices, preferred)
+	}
+
+	cursor := 0
+	for i, c := range choices {
+		if c == preferred {
+			cursor = i
+		}
+	}
+
+	title := fmt.Sprintf("%d conflicting cursor-acp plugin entries in opencode.json", len(variants))
+	if len(variants) == 1 {
+		title = "opencode.json loads cursor-acp differently from this install"
+	}
+
+	m.prompt = &taskPrompt{
+		title:   title,
+		body:    body,
+		choices: choices,
+		cursor:  cursor,
+		options: []promptOption{
+			{key: "enter", label: "Keep selected entry", apply: confirmPluginEntryPrompt},
+			{key: "k", label: "Leave as is"},
+		},
+	}
+	return nil
+}
+
+// confirmPluginEntryPrompt shows the plugin array diff for the chosen entry
+// before anything is written.
+func confirmPluginEntryPrompt(m *model, spec string) error {
+	config, err := opencodeconfig.Load(m.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	before := append([]opencodeconfig.PluginEntry(nil), config.Plugin...)
+	config.SetCursorACPPlugin(spec)
+	diff := pluginDiff(before, config.Plugin)
+
+	m.prompt = &taskPrompt{
+		title: "Apply this change to the plugin array?",
+		body:  diff,
+		options: []promptOption{
+			{key: "y", label: "Apply", apply: func(m *model, _ string) error {
+				return setPluginEntry(m, spec)
+			}},
+			{key: "n", label: "Leave as is"},
+		},
+	}
+	return nil
+}
+
+func setPluginEntry(m *model, spec string) error {
+	_ = backupConfigToDisk(m.configPath, m.currentTaskName())
+
+	config, err := opencodeconfig.Load(m.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	before := config.Clone()
+	config.SetCursorACPPlugin(spec)
+	return saveTrackedConfig(m.configPath, before, config)
+}
+
+// pluginDiff renders a line diff between two plugin arrays: "-" for removed
+// entries, "+" for added ones and unchanged entries indented.
+func pluginDiff(before, after []opencodeconfig.PluginEntry) []string {
+	return lineDiff(pluginLines(before), pluginLines(after))
+}
+
+// lineDiff diffs two line slices the same way pluginDiff does.
+func lineDiff(a, b []string) []string {
+	// Longest common subsequence table, filled from the end.
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out = append(out, "  "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, "- "+a[i])
+			i++
+		default:
+			out = append(out, "+ "+b[j])
+			j++
+		}
+	}
+	return out
+}
+
+func pluginLines(entries []opencodeconfig.PluginEntry) []string {
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		data, _ := e.MarshalJSON()
+		lines[i] = string(data)
+	}
+	return lines
+}
@@ -0,0 +1,76 @@
+// cmd/installer/plugins_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+func TestPluginDiff(t *testing.T) {
+	before := []opencodeconfig.PluginEntry{
+		opencodeconfig.Plugin("other"),
+		opencodeconfig.Plugin("cursor-acp"),
+		opencodeconfig.Plugin(npmPackage + "@latest"),
+		opencodeconfig.Plugin("cursor-acp-auth"),
+	}
+	after := []opencodeconfig.PluginEntry{
+		opencodeconfig.Plugin("other"),
+		opencodeconfig.Plugin(npmPackage + "@latest"),
+	}
+
+	want := []string{
+		`  "other"`,
+		`- "cursor-acp"`,
+		`  "` + npmPackage + `@latest"`,
+		`- "cursor-acp-auth"`,
+	}
+	if got := pluginDiff(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("diff =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestNormalizePluginEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "opencode.json")
+	data := `{"plugin": ["cursor-acp", "@rama_nigg/open-cursor@latest", "@rama_nigg/open-cursor@2.3.9", "cursor-acp-auth"]}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &model{configPath: path, mode: modeQuickInstall, npmTag: "latest"}
+	if err := normalizePluginEntries(m); err != nil {
+		t.Fatal(err)
+	}
+	if m.prompt == nil {
+		t.Fatal("expected a prompt for conflicting entries")
+	}
+	if got := m.prompt.choices[m.prompt.cursor]; got != npmPackage+"@latest" {
+		t.Errorf("preselected %q, want the quick-install entry", got)
+	}
+
+	if err := setPluginEntry(m, npmPackage+"@2.3.9"); err != nil {
+		t.Fatal(err)
+	}
+	config, err := opencodeconfig.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Plugin) != 1 || config.Plugin[0].Spec != npmPackage+"@2.3.9" {
+		t.Errorf("plugin = %v", config.Plugin)
+	}
+}
+
+func TestValidateConfigAllowsDeclinedDuplicates(t *testing.T) {
+	m := newTestModel(t)
+	writeTestConfig(t, m.configPath, `{
+  "plugin": ["cursor-acp", "`+npmPackage+`@latest", "cursor-acp"],
+  "provider": {"cursor-acp": {"options": {"baseURL": "http://127.0.0.1:32124/v1"}}}
+}`)
+
+	if err := validateConfig(m); err != nil {
+		t.Errorf("duplicates the user kept failed validation: %v", err)
+	}
+}
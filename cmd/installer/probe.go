@@ -0,0 +1,196 @@
+// cmd/installer/probe.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+const (
+	probeConcurrency = 4
+	probeTimeout     = 45 * time.Second
+	probePrompt      = "Reply with the single word OK."
+)
+
+// modelProbeStatus is the outcome of a single probe request.
+type modelProbeStatus int
+
+const (
+	probeUsable modelProbeStatus = iota
+	probeDenied
+	probeError
+)
+
+func (s modelProbeStatus) String() string {
+	switch s {
+	case probeUsable:
+		return "usable"
+	case probeDenied:
+		return "denied"
+	default:
+		return "error"
+	}
+}
+
+type modelProbeResult struct {
+	id     string
+	status modelProbeStatus
+	detail string
+}
+
+// probeDeniedMarkers are the cursor-agent messages the plugin itself treats
+// as the model being unavailable to this account (src/utils/errors.ts
+// parseAgentError). Anything else, usage limits included, is an error.
+var probeDeniedMarkers = []string{
+	"cannot use this model",
+	"model not found",
+	"invalid model",
+}
+
+// runModelProbes sends a minimal prompt to every model through the given
+// cursor-agent binary, at most concurrency at a time, and classifies each
+// outcome. Results are returned sorted by model id.
+func runModelProbes(ctx context.Context, binary string, ids []string, concurrency int, timeout time.Duration) []modelProbeResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]modelProbeResult, len(ids))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = modelProbeResult{id: id, status: probeError, detail: "cancelled"}
+				return
+			}
+			results[i] = probeModel(ctx, binary, id, timeout)
+		}(i, id)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(a, b int) bool { return results[a].id < results[b].id })
+	return results
+}
+
+func probeModel(ctx context.Context, binary, id string, timeout time.Duration) modelProbeResult {
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(probeCtx, binary, "--print", "--output-format", "text", "--model", id, probePrompt)
+	// Don't wait on grandchildren still holding the output pipe after a kill.
+	cmd.WaitDelay = time.Second
+	output, err := cmd.CombinedOutput()
+	result := modelProbeResult{id: id}
+
+	clean := strings.TrimSpace(stripANSI(string(output)))
+	lower := strings.ToLower(clean)
+	for _, marker := range probeDeniedMarkers {
+		if strings.Contains(lower, marker) {
+			result.status = probeDenied
+			result.detail = summarizeRawOutput(clean)
+			return result
+		}
+	}
+
+	switch {
+	case errors.Is(probeCtx.Err(), context.DeadlineExceeded):
+		result.status = probeError
+		result.detail = fmt.Sprintf("timed out after %s", timeout)
+	case err != nil:
+		result.status = probeError
+		result.detail = summarizeRawOutput(clean)
+		if result.detail == "" {
+			result.detail = err.Error()
+		}
+	default:
+		result.status = probeUsable
+	}
+	return result
+}
+
+// probeConfiguredModels is the "Probe models" task. It probes every model in
+// provider.cursor-acp.models and pauses the pipeline on the results, offering
+// to drop the failing ones from the config.
+func probeConfiguredModels(m *model) error {
+	config, err := opencodeconfig.Load(m.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	ids := config.CursorACP().ModelIDs()
+	if len(ids) == 0 {
+		return fmt.Errorf("no cursor-acp models configured to probe")
+	}
+
+	results := runModelProbes(m.ctx, "cursor-agent", ids, probeConcurrency, probeTimeout)
+
+	var usable, failing []string
+	var body []string
+	for _, r := range results {
+		if r.status == probeUsable {
+			usable = append(usable, r.id)
+			continue
+		}
+		failing = append(failing, r.id)
+		body = append(body, fmt.Sprintf("%-6s %s - %s", r.status, r.id, r.detail))
+	}
+	if len(usable) > 0 {
+		body = append([]string{fmt.Sprintf("usable (%d): %s", len(usable), strings.Join(usable, ", "))}, body...)
+	}
+
+	if len(failing) == 0 {
+		m.prompt = &taskPrompt{
+			title:   fmt.Sprintf("All %d models are usable", len(results)),
+			body:    body,
+			options: []promptOption{{key: "enter", label: "Continue"}},
+		}
+		return nil
+	}
+
+	m.prompt = &taskPrompt{
+		title: fmt.Sprintf("%d of %d models are not usable on this account", len(failing), len(results)),
+		body:  body,
+		options: []promptOption{
+			{key: "x", label: "Exclude failing models from opencode.json", apply: func(m *model, _ string) error {
+				return excludeModelsFromConfig(m, failing)
+			}},
+			{key: "k", label: "Keep all models"},
+		},
+	}
+	return nil
+}
+
+// excludeModelsFromConfig removes the given ids from provider.cursor-acp.models.
+func excludeModelsFromConfig(m *model, ids []string) error {
+	_ = backupConfigToDisk(m.configPath, m.currentTaskName())
+
+	config, err := opencodeconfig.Load(m.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	provider := config.CursorACP()
+	if provider == nil {
+		return nil
+	}
+	for _, id := range ids {
+		delete(provider.Models, id)
+	}
+
+	return config.Save(m.configPath)
+}
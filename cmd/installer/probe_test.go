@@ -0,0 +1,103 @@
+// cmd/installer/probe_test.go
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubCursorAgent is a fake cursor-agent that answers per --model id.
+const stubCursorAgent = `#!/bin/sh
+while [ $# -gt 0 ]; do
+  if [ "$1" = "--model" ]; then model="$2"; fi
+  shift
+done
+case "$model" in
+  auto|sonnet-4.5) echo OK ;;
+  opus-4.6) echo "Cannot use this model: opus-4.6. Upgrade to Pro." >&2; exit 1 ;;
+  gpt-5) echo "Error: model not found: gpt-5" >&2; exit 1 ;;
+  flaky) echo "Service not available, please upgrade your client or retry" >&2; exit 1 ;;
+  limited) echo "You've hit your usage limit" >&2; exit 1 ;;
+  slow) sleep 5; echo OK ;;
+  *) echo "connection reset" >&2; exit 2 ;;
+esac
+`
+
+func writeStubCursorAgent(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cursor-agent")
+	if err := os.WriteFile(path, []byte(stubCursorAgent), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunModelProbesClassifiesOutcomes(t *testing.T) {
+	stub := writeStubCursorAgent(t)
+	ids := []string{"sonnet-4.5", "opus-4.6", "auto", "broken", "slow", "gpt-5", "flaky", "limited"}
+
+	results := runModelProbes(context.Background(), stub, ids, 2, 500*time.Millisecond)
+
+	want := map[string]modelProbeStatus{
+		"auto":       probeUsable,
+		"broken":     probeError,
+		"flaky":      probeError,
+		"gpt-5":      probeDenied,
+		"limited":    probeError,
+		"opus-4.6":   probeDenied,
+		"slow":       probeError,
+		"sonnet-4.5": probeUsable,
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, r := range results {
+		if i > 0 && results[i-1].id > r.id {
+			t.Errorf("results not sorted: %s before %s", results[i-1].id, r.id)
+		}
+		if r.status != want[r.id] {
+			t.Errorf("%s: status = %s, want %s (%s)", r.id, r.status, want[r.id], r.detail)
+		}
+	}
+}
+
+func TestRunModelProbesRespectsConcurrency(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "running")
+	stub := filepath.Join(dir, "cursor-agent")
+	script := "#!/bin/sh\nmkdir \"" + counter + "-$$\"\nls -d \"" + counter + "\"-* | wc -l >> \"" + counter + ".log\"\nsleep 0.2\nrmdir \"" + counter + "-$$\"\necho OK\n"
+	if err := os.WriteFile(stub, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []string{"a", "b", "c", "d", "e", "f"}
+	results := runModelProbes(context.Background(), stub, ids, 2, 5*time.Second)
+	for _, r := range results {
+		if r.status != probeUsable {
+			t.Fatalf("%s: %s (%s)", r.id, r.status, r.detail)
+		}
+	}
+
+	data, err := os.ReadFile(counter + ".log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	peak := 0
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		n, err := strconv.Atoi(strings.TrimSpace(line))
+		if err != nil {
+			t.Fatalf("bad counter line %q", line)
+		}
+		if n > peak {
+			peak = n
+		}
+	}
+	if peak > 2 {
+		t.Errorf("peak concurrency = %d, want <= 2", peak)
+	}
+}
@@ -0,0 +1,392 @@
+// cmd/installer/repair.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+// healthStatus is the state of one installed component.
+type healthStatus int
+
+const (
+	healthOK healthStatus = iota
+	healthBroken
+	healthNA // not part of this install
+)
+
+// componentHealth is one line of the repair health list. fix is nil when
+// there is nothing to do or no automatic fix.
+type componentHealth struct {
+	name   string
+	status healthStatus
+	detail string
+	fix    func(*model) error
+}
+
+func (h componentHealth) mark() string {
+	switch h.status {
+	case healthOK:
+		return checkMark.String()
+	case healthBroken:
+		return failMark.String()
+	default:
+		return skipMark.String()
+	}
+}
+
+// inspectInstall checks every installed component, in the order repair fixes
+// them.
+func inspectInstall(m *model) []componentHealth {
+	config, configErr := opencodeconfig.Load(m.configPath)
+	return []componentHealth{
+		checkPluginFile(m, config),
+		checkPluginTarget(m),
+		checkPluginEntries(config, configErr),
+		checkBaseURL(config, configErr),
+		checkModels(config, configErr),
+		checkAiSdk(m),
+	}
+}
+
+// usesPluginFile reports whether this install loads the plugin through
+// plugin/cursor-acp.js rather than the npm package in the plugin array.
+func usesPluginFile(m *model, config *opencodeconfig.Config) bool {
+	if pathExists(pluginFilePath(m.pluginDir)) || detectLinkMode(pluginFilePath(m.pluginDir)) != "" {
+		return true
+	}
+	if mf, err := loadInstallManifest(); err == nil && mf.PluginEntry != "" {
+		return true
+	}
+	return config != nil && config.HasPlugin(func(e opencodeconfig.PluginEntry) bool {
+		return e.Kind() == opencodeconfig.PluginLocal
+	})
+}
+
+func checkPluginFile(m *model, config *opencodeconfig.Config) componentHealth {
+	h := componentHealth{name: "Plugin file"}
+	path := pluginFilePath(m.pluginDir)
+	if !usesPluginFile(m, config) {
+		h.status, h.detail = healthNA, "not used (npm package in the plugin array)"
+		return h
+	}
+
+	switch mode := detectLinkMode(path); {
+	case mode == "":
+		h.status, h.detail = healthBroken, path+" is missing"
+	case mode == linkModeCopy:
+		h.detail = path + " (copy)"
+		return h
+	default:
+		if _, err := filepath.EvalSymlinks(path); err == nil {
+			target, _ := os.Readlink(path)
+			h.detail = path + " → " + target
+			return h
+		}
+		target, _ := os.Readlink(path)
+		h.status, h.detail = healthBroken, fmt.Sprintf("%s → %s is dangling", path, target)
+	}
+	if entry := expectedPluginEntry(m); entry != "" {
+		h.detail += "; relink to " + entry
+		h.fix = repairPluginLink
+	}
+	return h
+}
+
+func checkPluginTarget(m *model) componentHealth {
+	h := componentHealth{name: "Plugin target"}
+	path := pluginFilePath(m.pluginDir)
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil || detectLinkMode(path) == linkModeCopy {
+		h.status, h.detail = healthNA, "no symlink to check"
+		return h
+	}
+	if !isNpmPackagePath(target) {
+		h.detail = "local build"
+		return h
+	}
+
+	current := npmGlobalEntry()
+	if current == "" {
+		h.detail = "npm package (npm not found to compare)"
+		return h
+	}
+	if resolved, err := filepath.EvalSymlinks(current); err == nil && resolved == target {
+		h.detail = "current npm global install"
+		return h
+	}
+	h.status = healthBroken
+	h.detail = fmt.Sprintf("points at %s, but npm now installs to %s", target, current)
+	h.fix = repairPluginLink
+	return h
+}
+
+func checkPluginEntries(config *opencodeconfig.Config, err error) componentHealth {
+	h := componentHealth{name: "Plugin entries"}
+	if err != nil {
+		h.status, h.detail = healthBroken, "cannot read config: "+err.Error()
+		return h
+	}
+	variants := config.CursorACPVariants()
+	switch len(variants) {
+	case 0:
+		h.status, h.detail = healthBroken, "no cursor-acp entry in the plugin array"
+		h.fix = func(m *model) error { return setPluginEntry(m, repairPluginSpec(m)) }
+	case 1:
+		h.detail = describePluginEntry(config.Plugin[variants[0]])
+	default:
+		var specs []string
+		for _, i := range variants {
+			specs = append(specs, fmt.Sprintf("%q", config.Plugin[i].Spec))
+		}
+		h.status, h.detail = healthBroken, fmt.Sprintf("%d entries load cursor-acp: %s", len(variants), strings.Join(specs, ", "))
+		h.fix = func(m *model) error {
+			if repairPluginSpec(m) == opencodeconfig.ProviderID {
+				m.mode = modeBuildFromSource
+			}
+			return normalizePluginEntries(m)
+		}
+	}
+	return h
+}
+
+func checkBaseURL(config *opencodeconfig.Config, err error) componentHealth {
+	h := componentHealth{name: "Provider baseURL"}
+	if err != nil {
+		h.status, h.detail = healthBroken, "cannot read config: "+err.Error()
+		return h
+	}
+	p := config.CursorACP()
+	switch {
+	case p == nil:
+		h.status, h.detail = healthBroken, "no "+configKeyProvider+" block"
+		h.fix = updateConfig
+	case p.Options == nil || p.Options.BaseURL == "":
+		h.status, h.detail = healthBroken, "options.baseURL is missing; set it to "+opencodeconfig.DefaultBaseURL
+		h.fix = repairBaseURL
+	default:
+		h.detail = p.Options.BaseURL
+	}
+	return h
+}
+
+func checkModels(config *opencodeconfig.Config, err error) componentHealth {
+	h := componentHealth{name: "Models"}
+	if err != nil || config.CursorACP() == nil {
+		h.status, h.detail = healthNA, "no provider to check"
+		return h
+	}
+	if n := len(config.CursorACP().ModelIDs()); n > 0 {
+		h.detail = fmt.Sprintf("%d models", n)
+		return h
+	}
+	h.status, h.detail = healthBroken, "models map is empty; fetch from cursor-agent"
+	h.fix = fetchAndAddModels
+	return h
+}
+
+func checkAiSdk(m *model) componentHealth {
+	h := componentHealth{name: "AI SDK"}
+	path := filepath.Join(filepath.Dir(m.configPath), "node_modules", "@ai-sdk", "openai-compatible")
+	if pathExists(path) {
+		h.detail = path
+		return h
+	}
+	h.status, h.detail = healthBroken, path+" is missing"
+	h.fix = installAiSdk
+	return h
+}
+
+// isNpmPackagePath reports whether path lies inside an installed copy of the
+// npm package, wherever its node_modules is.
+func isNpmPackagePath(path string) bool {
+	return strings.Contains(filepath.ToSlash(path), "node_modules/"+npmPackage+"/")
+}
+
+// npmGlobalEntry is plugin-entry.js in the current npm global install, or ""
+// when there is none.
+func npmGlobalEntry() string {
+	root := npmGlobalRoot()
+	if root == "" {
+		return ""
+	}
+	entry, err := pluginEntryPath(filepath.Join(root, filepath.FromSlash(npmPackage)))
+	if err != nil {
+		return ""
+	}
+	return entry
+}
+
+// expectedPluginEntry is what cursor-acp.js should point at: the current npm
+// global install if the link went to an npm install, else the manifest's
+// entry, this checkout's build or the npm global install, whichever exists.
+func expectedPluginEntry(m *model) string {
+	var candidates []string
+	npmEntry := npmGlobalEntry()
+	if target, err := os.Readlink(pluginFilePath(m.pluginDir)); err == nil && isNpmPackagePath(target) {
+		candidates = append(candidates, npmEntry)
+	}
+	if mf, err := loadInstallManifest(); err == nil {
+		candidates = append(candidates, mf.PluginEntry)
+	}
+	candidates = append(candidates, filepath.Join(m.projectDir, "dist", "plugin-entry.js"), npmEntry)
+
+	for _, entry := range candidates {
+		if info, err := os.Stat(entry); entry != "" && err == nil && info.Size() > 0 {
+			return entry
+		}
+	}
+	return ""
+}
+
+// repairPluginSpec is the plugin entry this install should have.
+func repairPluginSpec(m *model) string {
+	config, _ := opencodeconfig.Load(m.configPath)
+	if usesPluginFile(m, config) {
+		return opencodeconfig.ProviderID
+	}
+	return npmPackage + "@" + m.npmTag
+}
+
+// repairPluginLink relinks cursor-acp.js to expectedPluginEntry, keeping how
+// it was linked.
+func repairPluginLink(m *model) error {
+	entry := expectedPluginEntry(m)
+	if entry == "" {
+		return fmt.Errorf("no built plugin found to link; reinstall with Build from Source")
+	}
+	m.pluginEntry = entry
+	if mode := detectLinkMode(pluginFilePath(m.pluginDir)); mode != "" {
+		m.linkMode = mode
+	}
+	if mf, err := loadInstallManifest(); err == nil && mf.LinkMode != "" {
+		m.linkMode = mf.LinkMode
+	}
+	return createSymlink(m)
+}
+
+func repairBaseURL(m *model) error {
+	_ = backupConfigToDisk(m.configPath, m.currentTaskName())
+	config, err := opencodeconfig.Load(m.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	before := config.Clone()
+	if _, err := config.EnsureCursorACP(); err != nil {
+		return err
+	}
+	return saveTrackedConfig(m.configPath, before, config)
+}
+
+// startRepair runs the pending migrations and the fix for each broken
+// component, then checks again.
+func (m model) startRepair() (tea.Model, tea.Cmd) {
+	m.step = stepInstalling
+	m.isRepair = true
+
+	m.tasks = nil
+	if len(m.legacy) > 0 {
+		m.tasks = append(m.tasks, migrationsTask(true))
+	}
+	for _, h := range m.health {
+		if h.fix != nil {
+			m.tasks = append(m.tasks, installTask{name: "Fix " + strings.ToLower(h.name), description: "Repairing " + strings.ToLower(h.name), execute: h.fix, optional: true, status: statusPending})
+		}
+	}
+	m.tasks = append(m.tasks, installTask{name: "Check health", description: "Inspecting components again", execute: recheckHealth, optional: true, status: statusPending})
+
+	m.currentTaskIndex = 0
+	m.tasks[0].status = statusRunning
+	return m, tea.Batch(m.spinner.Tick, executeTaskCmd(0, &m))
+}
+
+// recheckHealth shows the health list after the fixes next to the one from
+// before.
+func recheckHealth(m *model) error {
+	after := inspectInstall(m)
+	fixed, broken := 0, 0
+	var body []string
+	for i, h := range after {
+		line := h.mark() + " " + h.name + ": " + h.detail
+		if i < len(m.health) && m.health[i].status == healthBroken {
+			if h.status == healthBroken {
+				broken++
+			} else {
+				fixed++
+				line += " (was: " + m.health[i].detail + ")"
+			}
+		}
+		body = append(body, line)
+	}
+
+	title := fmt.Sprintf("Fixed %d of %d broken components", fixed, fixed+broken)
+	m.prompt = &taskPrompt{
+		title:   title,
+		body:    body,
+		options: []promptOption{{key: "enter", label: "Continue"}},
+	}
+	return nil
+}
+
+func (m model) handleRepairKeys(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "enter", "y":
+		if len(m.legacy) > 0 {
+			return m.startRepair()
+		}
+		for _, h := range m.health {
+			if h.fix != nil {
+				return m.startRepair()
+			}
+		}
+		m.step = stepWelcome
+	case "n", "b", "q":
+		m.step = stepWelcome
+	}
+	return m, nil
+}
+
+func (m model) renderRepair() string {
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(Primary).Render("Installation health"))
+	b.WriteString("\n\n")
+
+	fixable := 0
+	for _, h := range m.health {
+		b.WriteString(fmt.Sprintf("  %s %s: %s\n", h.mark(), h.name, h.detail))
+		if h.fix != nil {
+			fixable++
+		} else if h.status == healthBroken {
+			b.WriteString(lipgloss.NewStyle().Foreground(FgMuted).Render("      no automatic fix"))
+			b.WriteString("\n")
+		}
+	}
+
+	if len(m.legacy) > 0 {
+		b.WriteString("\n")
+		b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(Primary).Render("Left by older versions"))
+		b.WriteString("\n\n")
+		for _, p := range m.legacy {
+			b.WriteString(fmt.Sprintf("  %s %s\n", skipMark.String(), p.description))
+			for _, f := range p.found {
+				b.WriteString(lipgloss.NewStyle().Foreground(FgMuted).Render("      " + f))
+				b.WriteString("\n")
+			}
+		}
+		fixable += len(m.legacy)
+	}
+
+	b.WriteString("\n")
+	if fixable == 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(SuccessColor).Render("Nothing to repair. Press Enter to go back"))
+		return b.String()
+	}
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(WarningColor).Render(fmt.Sprintf("Press Enter to fix %d issue(s), n to go back", fixable)))
+	return b.String()
+}
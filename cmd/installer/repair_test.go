@@ -0,0 +1,103 @@
+// cmd/installer/repair_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+func healthByName(list []componentHealth) map[string]componentHealth {
+	byName := make(map[string]componentHealth)
+	for _, h := range list {
+		byName[h.name] = h
+	}
+	return byName
+}
+
+func TestInspectInstallFindsDrift(t *testing.T) {
+	m := newTestModel(t)
+	writeTestConfig(t, m.configPath, `{
+		"plugin": ["cursor-acp", "@rama_nigg/open-cursor@latest"],
+		"provider": {"cursor-acp": {"name": "Cursor", "options": {}, "models": {}}}
+	}`)
+	if err := os.MkdirAll(m.pluginDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/gone/dist/plugin-entry.js", pluginFilePath(m.pluginDir)); err != nil {
+		t.Fatal(err)
+	}
+	writePluginEntry(t, m.projectDir)
+
+	health := healthByName(inspectInstall(m))
+	for _, name := range []string{"Plugin file", "Plugin entries", "Provider baseURL", "Models", "AI SDK"} {
+		if h := health[name]; h.status != healthBroken || h.fix == nil {
+			t.Errorf("%s = %v %q, want broken with a fix", name, h.status, h.detail)
+		}
+	}
+	if h := health["Plugin target"]; h.status != healthNA {
+		t.Errorf("Plugin target = %v %q, want n/a for a dangling link", h.status, h.detail)
+	}
+}
+
+func TestRepairFixesLinkAndBaseURL(t *testing.T) {
+	m := newTestModel(t)
+	writeTestConfig(t, m.configPath, `{
+		"plugin": ["cursor-acp"],
+		"provider": {"cursor-acp": {"name": "Cursor", "models": {"auto": {"name": "Auto"}}}}
+	}`)
+	if err := os.MkdirAll(m.pluginDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/gone/dist/plugin-entry.js", pluginFilePath(m.pluginDir)); err != nil {
+		t.Fatal(err)
+	}
+	entry := writePluginEntry(t, m.projectDir)
+
+	for _, h := range inspectInstall(m) {
+		if h.fix == nil || h.name == "AI SDK" {
+			continue
+		}
+		if err := h.fix(m); err != nil {
+			t.Fatalf("fix %s: %v", h.name, err)
+		}
+	}
+
+	if target, _ := os.Readlink(pluginFilePath(m.pluginDir)); target != entry {
+		t.Errorf("cursor-acp.js → %q, want %q", target, entry)
+	}
+	if p := loadTestConfig(t, m.configPath).CursorACP(); p.Options == nil || p.Options.BaseURL != opencodeconfig.DefaultBaseURL {
+		t.Errorf("baseURL not restored: %+v", p.Options)
+	}
+	health := healthByName(inspectInstall(m))
+	for _, name := range []string{"Plugin file", "Plugin entries", "Provider baseURL", "Models"} {
+		if health[name].status != healthOK {
+			t.Errorf("%s after repair = %v %q", name, health[name].status, health[name].detail)
+		}
+	}
+}
+
+func TestIsNpmPackagePath(t *testing.T) {
+	if !isNpmPackagePath("/usr/lib/node_modules/@rama_nigg/open-cursor/dist/plugin-entry.js") {
+		t.Error("expected an npm package path")
+	}
+	if isNpmPackagePath("/home/u/src/open-cursor/dist/plugin-entry.js") {
+		t.Error("a checkout is not an npm package path")
+	}
+}
+
+func TestCheckAiSdkUsesConfigDir(t *testing.T) {
+	m := newTestModel(t)
+	dir := t.TempDir()
+	m.configPath = filepath.Join(dir, "opencode.json")
+	sdk := filepath.Join(dir, "node_modules", "@ai-sdk", "openai-compatible")
+	if err := os.MkdirAll(sdk, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if h := checkAiSdk(m); h.status != healthOK || h.detail != sdk {
+		t.Errorf("AI SDK = %v %q, want found in %s", h.status, h.detail, dir)
+	}
+}
@@ -0,0 +1,356 @@
+// cmd/installer/schedule.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	scheduleUnitName = "opencode-cursor-sync"
+	// scheduleMarker tags every file and crontab line schedule-sync writes so
+	// removal never touches entries it didn't create.
+	scheduleMarker  = "managed-by: opencode-cursor-installer"
+	minSyncInterval = 15 * time.Minute
+)
+
+func cmdScheduleSync(args []string) error {
+	fs, configPath := newFlagSet("schedule-sync")
+	interval := fs.Duration("interval", 24*time.Hour, "how often to run sync-models (minimum 15m)")
+	remove := fs.Bool("remove", false, "remove the scheduled sync")
+	useCron := fs.Bool("cron", false, "use crontab even when systemd --user is available")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *remove {
+		removed, err := removeScheduledSync()
+		if err != nil {
+			return err
+		}
+		if len(removed) == 0 {
+			fmt.Println("No scheduled sync found")
+		}
+		for _, r := range removed {
+			fmt.Printf("Removed %s\n", r)
+		}
+		return nil
+	}
+
+	if *interval < minSyncInterval {
+		return fmt.Errorf("interval %s is shorter than the minimum %s", *interval, minSyncInterval)
+	}
+	useSystemd := !*useCron && systemdUserAvailable()
+	if !useSystemd {
+		// Check before the existing schedule is removed below.
+		if _, err := cronSchedule(*interval); err != nil {
+			return err
+		}
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate installer binary: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("failed to resolve installer binary: %w", err)
+	}
+	if strings.HasPrefix(exe, os.TempDir()) {
+		return fmt.Errorf("installer is running from a temporary build (%s); build it with `go build` first", exe)
+	}
+
+	path, err := schedulePath()
+	if err != nil {
+		return err
+	}
+
+	stateDir, err := ensureStateDir()
+	if err != nil {
+		return err
+	}
+	logPath := filepath.Join(stateDir, "sync.log")
+
+	// Replace rather than stack schedules.
+	if _, err := removeScheduledSync(); err != nil {
+		return err
+	}
+
+	if useSystemd {
+		if err := installSystemdTimer(exe, *configPath, path, *interval); err != nil {
+			return err
+		}
+		fmt.Printf("Installed systemd user timer %s.timer (every %s)\n", scheduleUnitName, *interval)
+	} else {
+		if err := installCronEntry(exe, *configPath, logPath, path, *interval); err != nil {
+			return err
+		}
+		fmt.Printf("Installed crontab entry (every %s)\n", *interval)
+	}
+	fmt.Printf("Results are logged to %s\n", logPath)
+	return nil
+}
+
+// schedulePath is the PATH scheduled syncs run with. Neither cron nor
+// systemd --user sees the login shell's PATH, which is usually where
+// cursor-agent (~/.local/bin) comes from, so its directory goes first.
+func schedulePath() (string, error) {
+	agent, err := exec.LookPath("cursor-agent")
+	if err != nil {
+		return "", fmt.Errorf("cursor-agent not found on PATH; scheduled syncs need it: %w", err)
+	}
+	if agent, err = filepath.Abs(agent); err != nil {
+		return "", err
+	}
+	dirs := []string{filepath.Dir(agent)}
+	for _, dir := range []string{"/usr/local/bin", "/usr/bin", "/bin"} {
+		if dir != dirs[0] {
+			dirs = append(dirs, dir)
+		}
+	}
+	return strings.Join(dirs, string(os.PathListSeparator)), nil
+}
+
+// systemdQuote quotes s as one unit file word: backslashes and quotes are
+// escaped and % is doubled so systemd expands no specifiers. Other
+// characters, UTF-8 included, pass through; Go's %q would turn those into
+// escapes systemd doesn't know.
+func systemdQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "%", "%%")
+	return `"` + r.Replace(s) + `"`
+}
+
+// systemdExecQuote is systemdQuote for ExecStart, which also expands $.
+func systemdExecQuote(s string) string {
+	return systemdQuote(strings.ReplaceAll(s, "$", "$$"))
+}
+
+// shellQuote single-quotes s for /bin/sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func systemdUserAvailable() bool {
+	if !commandExists("systemctl") {
+		return false
+	}
+	return exec.Command("systemctl", "--user", "show-environment").Run() == nil
+}
+
+func systemdUserUnitDir() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "systemd", "user"), nil
+}
+
+// systemdService is the service unit the timer starts.
+func systemdService(exe, configPath, path string) string {
+	return fmt.Sprintf(`# %s
+[Unit]
+Description=Sync cursor-acp models into OpenCode config
+
+[Service]
+Type=oneshot
+Environment=%s
+ExecStart=%s sync-models --config %s
+`, scheduleMarker, systemdQuote("PATH="+path), systemdExecQuote(exe), systemdExecQuote(configPath))
+}
+
+func installSystemdTimer(exe, configPath, path string, interval time.Duration) error {
+	unitDir, err := systemdUserUnitDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(unitDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", unitDir, err)
+	}
+
+	service := systemdService(exe, configPath, path)
+
+	timer := fmt.Sprintf(`# %s
+[Unit]
+Description=Periodically sync cursor-acp models into OpenCode config
+
+[Timer]
+OnBootSec=5min
+OnUnitActiveSec=%ds
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`, scheduleMarker, int(interval.Seconds()))
+
+	if err := os.WriteFile(filepath.Join(unitDir, scheduleUnitName+".service"), []byte(service), 0644); err != nil {
+		return fmt.Errorf("failed to write service unit: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(unitDir, scheduleUnitName+".timer"), []byte(timer), 0644); err != nil {
+		return fmt.Errorf("failed to write timer unit: %w", err)
+	}
+
+	if err := runCommand("systemctl --user daemon-reload", exec.Command("systemctl", "--user", "daemon-reload"), nil); err != nil {
+		return err
+	}
+	enableCmd := exec.Command("systemctl", "--user", "enable", "--now", scheduleUnitName+".timer")
+	return runCommand("systemctl --user enable "+scheduleUnitName+".timer", enableCmd, nil)
+}
+
+// cronSchedule converts an interval to a crontab expression. Cron steps
+// restart every hour, day or month, so only intervals that divide those
+// evenly run at a steady pace; anything else is rejected rather than
+// approximated.
+func cronSchedule(interval time.Duration) (string, error) {
+	switch {
+	case interval < time.Hour && interval%time.Minute == 0 && 60%int(interval.Minutes()) == 0:
+		return fmt.Sprintf("*/%d * * * *", int(interval.Minutes())), nil
+	case interval >= time.Hour && interval < 24*time.Hour && interval%time.Hour == 0 && 24%int(interval.Hours()) == 0:
+		return fmt.Sprintf("0 */%d * * *", int(interval.Hours())), nil
+	case interval == 24*time.Hour:
+		return "0 3 * * *", nil
+	case interval == 7*24*time.Hour:
+		return "0 3 * * 0", nil
+	}
+	return "", fmt.Errorf("crontab can't run every %s evenly; use 15m, 20m or 30m, a whole number of hours dividing 24, 24h or 168h (systemd timers take any interval)", interval)
+}
+
+func readCrontab() (string, error) {
+	if !commandExists("crontab") {
+		return "", fmt.Errorf("neither systemd --user nor crontab is available")
+	}
+	output, err := exec.Command("crontab", "-l").CombinedOutput()
+	if err != nil {
+		// "no crontab for <user>" is an empty crontab, not a failure.
+		if strings.Contains(string(output), "no crontab") {
+			return "", nil
+		}
+		return "", NewExecError("crontab -l failed", string(output), err)
+	}
+	return string(output), nil
+}
+
+func writeCrontab(content string) error {
+	cmd := exec.Command("crontab", "-")
+	cmd.Stdin = strings.NewReader(content)
+	return runCommand("crontab -", cmd, nil)
+}
+
+// cronLine is the crontab entry for schedule. The command is sh with single
+// quotes; cron turns an unescaped % into a newline, so those are escaped.
+func cronLine(schedule, exe, configPath, logPath, path string) string {
+	// sync-models records its own result in the log; keep stderr for crashes.
+	command := fmt.Sprintf("PATH=%s %s sync-models --config %s >/dev/null 2>>%s",
+		shellQuote(path), shellQuote(exe), shellQuote(configPath), shellQuote(logPath))
+	return fmt.Sprintf("%s %s # %s", schedule, strings.ReplaceAll(command, "%", `\%`), scheduleMarker)
+}
+
+func installCronEntry(exe, configPath, logPath, path string, interval time.Duration) error {
+	schedule, err := cronSchedule(interval)
+	if err != nil {
+		return err
+	}
+	current, err := readCrontab()
+	if err != nil {
+		return err
+	}
+
+	line := cronLine(schedule, exe, configPath, logPath, path)
+
+	content := strings.TrimRight(current, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	return writeCrontab(content + line + "\n")
+}
+
+// removeScheduledSync removes the systemd units and crontab lines carrying
+// scheduleMarker and returns what it removed.
+func removeScheduledSync() ([]string, error) {
+	var removed []string
+
+	if unitDir, err := systemdUserUnitDir(); err == nil {
+		timerPath := filepath.Join(unitDir, scheduleUnitName+".timer")
+		servicePath := filepath.Join(unitDir, scheduleUnitName+".service")
+		if hasScheduleMarker(timerPath) && systemdUserAvailable() {
+			disableCmd := exec.Command("systemctl", "--user", "disable", "--now", scheduleUnitName+".timer")
+			_ = runCommand("systemctl --user disable "+scheduleUnitName+".timer", disableCmd, nil)
+		}
+		for _, path := range []string{timerPath, servicePath} {
+			if !hasScheduleMarker(path) {
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				return removed, fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+			removed = append(removed, path)
+		}
+		if len(removed) > 0 && systemdUserAvailable() {
+			_ = exec.Command("systemctl", "--user", "daemon-reload").Run()
+		}
+	}
+
+	if commandExists("crontab") {
+		current, err := readCrontab()
+		if err != nil {
+			return removed, err
+		}
+		if content, dropped := stripScheduleLines(current); dropped > 0 {
+			if err := writeCrontab(content); err != nil {
+				return removed, err
+			}
+			removed = append(removed, fmt.Sprintf("%d crontab entry(s)", dropped))
+		}
+	}
+
+	return removed, nil
+}
+
+// stripScheduleLines returns crontab without the lines carrying
+// scheduleMarker, and how many it dropped.
+func stripScheduleLines(crontab string) (string, int) {
+	var kept []string
+	dropped := 0
+	for _, line := range strings.Split(strings.TrimRight(crontab, "\n"), "\n") {
+		if strings.Contains(line, scheduleMarker) {
+			dropped++
+			continue
+		}
+		kept = append(kept, line)
+	}
+	content := strings.Join(kept, "\n")
+	if strings.TrimSpace(content) != "" {
+		content += "\n"
+	}
+	return content, dropped
+}
+
+// findScheduledSync lists what removeScheduledSync would remove.
+func findScheduledSync() []string {
+	var found []string
+	if unitDir, err := systemdUserUnitDir(); err == nil {
+		for _, name := range []string{scheduleUnitName + ".timer", scheduleUnitName + ".service"} {
+			if path := filepath.Join(unitDir, name); hasScheduleMarker(path) {
+				found = append(found, path)
+			}
+		}
+	}
+	if commandExists("crontab") {
+		if current, err := readCrontab(); err == nil {
+			if n := strings.Count(current, scheduleMarker); n > 0 {
+				found = append(found, fmt.Sprintf("%d crontab entry(s)", n))
+			}
+		}
+	}
+	return found
+}
+
+func hasScheduleMarker(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(data), scheduleMarker)
+}
@@ -0,0 +1,104 @@
+// cmd/installer/schedule_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCronSchedule(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		want     string // "" means rejected
+	}{
+		{15 * time.Minute, "*/15 * * * *"},
+		{20 * time.Minute, "*/20 * * * *"},
+		{30 * time.Minute, "*/30 * * * *"},
+		{45 * time.Minute, ""},
+		{time.Hour, "0 */1 * * *"},
+		{90 * time.Minute, ""},
+		{6 * time.Hour, "0 */6 * * *"},
+		{8 * time.Hour, "0 */8 * * *"},
+		{5 * time.Hour, ""},
+		{24 * time.Hour, "0 3 * * *"},
+		{36 * time.Hour, ""},
+		{48 * time.Hour, ""},
+		{7 * 24 * time.Hour, "0 3 * * 0"},
+	}
+	for _, tt := range tests {
+		got, err := cronSchedule(tt.interval)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("cronSchedule(%s) = %q, want an error", tt.interval, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("cronSchedule(%s) = %q, %v, want %q", tt.interval, got, err, tt.want)
+		}
+	}
+}
+
+func TestStripScheduleLines(t *testing.T) {
+	ours := "*/30 * * * * \"/usr/bin/installer\" sync-models # " + scheduleMarker
+	tests := []struct {
+		name, crontab, want string
+		dropped             int
+	}{
+		{"empty", "", "", 0},
+		{"only ours", ours + "\n", "", 1},
+		{"keeps others", "MAILTO=me\n" + ours + "\n0 1 * * * backup.sh\n", "MAILTO=me\n0 1 * * * backup.sh\n", 1},
+		{"no trailing newline", "0 1 * * * backup.sh\n" + ours, "0 1 * * * backup.sh\n", 1},
+		{"stacked", ours + "\n" + ours + "\n", "", 2},
+		{"similar but unmarked", "0 1 * * * installer sync-models # managed by me\n", "0 1 * * * installer sync-models # managed by me\n", 0},
+	}
+	for _, tt := range tests {
+		got, dropped := stripScheduleLines(tt.crontab)
+		if got != tt.want || dropped != tt.dropped {
+			t.Errorf("%s: got %q (%d dropped), want %q (%d)", tt.name, got, dropped, tt.want, tt.dropped)
+		}
+	}
+}
+
+func TestScheduledSyncSetsPath(t *testing.T) {
+	const path = "/home/me/.local/bin:/usr/bin:/bin"
+
+	service := systemdService(`/opt/my tools/installer`, `/home/me/Dokumente/ö\cfg 100%.json`, path)
+	for _, want := range []string{
+		`Environment="PATH=/home/me/.local/bin:/usr/bin:/bin"`,
+		`ExecStart="/opt/my tools/installer" sync-models --config "/home/me/Dokumente/ö\\cfg 100%%.json"`,
+	} {
+		if !strings.Contains(service, want) {
+			t.Errorf("service unit lacks %s:\n%s", want, service)
+		}
+	}
+
+	line := cronLine("0 3 * * *", "/opt/installer", "/home/me/it's 100%.json", "/state/sync.log", path)
+	want := `0 3 * * * PATH='/home/me/.local/bin:/usr/bin:/bin' '/opt/installer' sync-models --config '/home/me/it'\''s 100\%.json' >/dev/null 2>>'/state/sync.log' # ` + scheduleMarker
+	if line != want {
+		t.Errorf("cron line =\n%s\nwant\n%s", line, want)
+	}
+}
+
+func TestSchedulePathFindsCursorAgent(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "cursor-agent"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	path, err := schedulePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(path, bin+string(os.PathListSeparator)) {
+		t.Errorf("path = %q, want it to start with %s", path, bin)
+	}
+
+	t.Setenv("PATH", "")
+	if _, err := schedulePath(); err == nil {
+		t.Error("scheduled without cursor-agent")
+	}
+}
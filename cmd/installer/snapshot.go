@@ -0,0 +1,470 @@
+// cmd/installer/snapshot.go
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// maxSnapshots is how many config dir snapshots are kept.
+const maxSnapshots = 10
+
+// snapshotMetaName is the first archive entry, describing the rest.
+const snapshotMetaName = ".snapshot.json"
+
+// snapshotPaths are the entries of ~/.config/opencode that install touches,
+// relative to that directory. opencode.json is among them so a restore never
+// leaves the config pointing at a plugin the rest of the snapshot removed.
+var snapshotPaths = []string{"opencode.json", "package.json", "bun.lock", "bun.lockb", "package-lock.json", "plugin"}
+
+// snapshotMeta describes a config dir snapshot. Paths in Scope that are
+// missing from Present didn't exist and are removed on restore.
+type snapshotMeta struct {
+	ID          string    `json:"id"`
+	Dir         string    `json:"dir"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Run         string    `json:"run,omitempty"`
+	Scope       []string  `json:"scope"`
+	Present     []string  `json:"present"`
+	NodeModules string    `json:"nodeModules"` // "included", "excluded" or "absent"
+}
+
+func (s snapshotMeta) present(path string) bool {
+	for _, p := range s.Present {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+func getSnapshotsDir() (string, error) {
+	stateDir, err := getStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "snapshots"), nil
+}
+
+func opencodeConfigDir() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "opencode"), nil
+}
+
+// createSnapshot archives the install-touched paths of dir into the
+// snapshots directory; callers prune afterwards. node_modules is left out
+// unless withNodeModules: the lockfile is enough to reinstall it.
+func createSnapshot(dir string, withNodeModules bool) (snapshotMeta, error) {
+	meta := snapshotMeta{
+		ID:        time.Now().Format("20060102-150405"),
+		Dir:       dir,
+		CreatedAt: time.Now(),
+		Run:       backupRun.id,
+		Scope:     append([]string{}, snapshotPaths...),
+	}
+	switch {
+	case !pathExists(filepath.Join(dir, "node_modules")):
+		meta.NodeModules = "absent"
+	case withNodeModules:
+		meta.NodeModules = "included"
+	default:
+		meta.NodeModules = "excluded"
+	}
+	if meta.NodeModules != "excluded" {
+		meta.Scope = append(meta.Scope, "node_modules")
+	}
+	for _, p := range meta.Scope {
+		if _, err := os.Lstat(filepath.Join(dir, p)); err == nil {
+			meta.Present = append(meta.Present, p)
+		}
+	}
+
+	snapshotsDir, err := getSnapshotsDir()
+	if err != nil {
+		return meta, err
+	}
+	if err := os.MkdirAll(snapshotsDir, 0755); err != nil {
+		return meta, fmt.Errorf("failed to create snapshots directory: %w", err)
+	}
+	for n := 1; pathExists(filepath.Join(snapshotsDir, meta.ID+".tar.gz")); n++ {
+		meta.ID = fmt.Sprintf("%s-%d", meta.CreatedAt.Format("20060102-150405"), n)
+	}
+
+	archivePath := filepath.Join(snapshotsDir, meta.ID+".tar.gz")
+	if err := writeSnapshotArchive(archivePath, meta); err != nil {
+		os.Remove(archivePath)
+		return meta, err
+	}
+	return meta, nil
+}
+
+func writeSnapshotArchive(archivePath string, meta snapshotMeta) error {
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	metaData, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: snapshotMetaName, Mode: 0644, Size: int64(len(metaData)), ModTime: meta.CreatedAt}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(metaData); err != nil {
+		return err
+	}
+
+	for _, p := range meta.Present {
+		root := filepath.Join(meta.Dir, p)
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			return addToArchive(tw, meta.Dir, path, info)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to archive %s: %w", root, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// addToArchive writes path, relative to dir, to tw. Symlinks are stored as
+// links, not followed.
+func addToArchive(tw *tar.Writer, dir, path string, info os.FileInfo) error {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return err
+	}
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	}
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = filepath.ToSlash(rel)
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// listSnapshots returns the recorded snapshots, newest first.
+func listSnapshots() ([]snapshotMeta, error) {
+	snapshotsDir, err := getSnapshotsDir()
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(filepath.Join(snapshotsDir, "*.tar.gz"))
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []snapshotMeta
+	for _, path := range matches {
+		meta, err := readSnapshotMeta(path)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, meta)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		if !snapshots[i].CreatedAt.Equal(snapshots[j].CreatedAt) {
+			return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+		}
+		return snapshots[i].ID > snapshots[j].ID
+	})
+	return snapshots, nil
+}
+
+// readSnapshotMeta reads the metadata entry at the start of an archive.
+func readSnapshotMeta(archivePath string) (snapshotMeta, error) {
+	var meta snapshotMeta
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return meta, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return meta, fmt.Errorf("failed to read %s: %w", archivePath, err)
+	}
+	tr := tar.NewReader(gz)
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != snapshotMetaName {
+		return meta, fmt.Errorf("%s is not a config dir snapshot", archivePath)
+	}
+	if err := json.NewDecoder(tr).Decode(&meta); err != nil {
+		return meta, fmt.Errorf("failed to parse %s: %w", archivePath, err)
+	}
+	return meta, nil
+}
+
+func pruneSnapshots(keep int) error {
+	snapshots, err := listSnapshots()
+	if err != nil {
+		return err
+	}
+	snapshotsDir, err := getSnapshotsDir()
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(snapshots); i++ {
+		if err := os.Remove(filepath.Join(snapshotsDir, snapshots[i].ID+".tar.gz")); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// findSnapshot looks a snapshot up by ID or "latest".
+func findSnapshot(id string) (snapshotMeta, error) {
+	snapshots, err := listSnapshots()
+	if err != nil {
+		return snapshotMeta{}, err
+	}
+	if len(snapshots) == 0 {
+		return snapshotMeta{}, fmt.Errorf("no snapshots recorded")
+	}
+	if id == "latest" {
+		return snapshots[0], nil
+	}
+	for _, s := range snapshots {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return snapshotMeta{}, fmt.Errorf("no snapshot %q (see `installer restore-snapshot --list`)", id)
+}
+
+// restoreSnapshot puts the snapshot's paths back as they were: the archive is
+// unpacked to a staging directory first, then each path in scope is replaced
+// or, if it didn't exist, removed. An excluded node_modules is reinstalled
+// from the restored lockfile with install, which runs in dir.
+func restoreSnapshot(meta snapshotMeta, install func(dir string) error) error {
+	snapshotsDir, err := getSnapshotsDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(meta.Dir, 0755); err != nil {
+		return err
+	}
+	staging, err := os.MkdirTemp(meta.Dir, ".snapshot-restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	if err := extractSnapshot(filepath.Join(snapshotsDir, meta.ID+".tar.gz"), staging, meta.Scope); err != nil {
+		return fmt.Errorf("failed to unpack snapshot %s: %w", meta.ID, err)
+	}
+
+	for _, p := range meta.Scope {
+		target := filepath.Join(meta.Dir, p)
+		if err := os.RemoveAll(target); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", target, err)
+		}
+		if !meta.present(p) {
+			continue
+		}
+		if err := os.Rename(filepath.Join(staging, p), target); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", target, err)
+		}
+	}
+
+	if meta.NodeModules == "excluded" && install != nil {
+		if err := install(meta.Dir); err != nil {
+			return fmt.Errorf("restored files, but reinstalling node_modules failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// extractSnapshot unpacks archivePath into dir, refusing entries outside
+// scope.
+func extractSnapshot(archivePath, dir string, scope []string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Name == snapshotMetaName {
+			continue
+		}
+
+		name := filepath.Clean(filepath.FromSlash(hdr.Name))
+		top := strings.SplitN(filepath.ToSlash(name), "/", 2)[0]
+		inScope := false
+		for _, p := range scope {
+			inScope = inScope || p == top
+		}
+		if !inScope || filepath.IsAbs(name) || strings.HasPrefix(name, "..") {
+			return fmt.Errorf("unexpected entry %q", hdr.Name)
+		}
+		target := filepath.Join(dir, name)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(hdr.Mode).Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			if err := out.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// bunInstallFromLockfile reinstalls node_modules in dir from its lockfile.
+func bunInstallFromLockfile(dir string) error {
+	if !pathExists(filepath.Join(dir, "package.json")) {
+		return nil
+	}
+	args := []string{"install"}
+	if pathExists(filepath.Join(dir, "bun.lock")) || pathExists(filepath.Join(dir, "bun.lockb")) {
+		args = append(args, "--frozen-lockfile")
+	}
+	cmd := exec.Command("bun", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("bun %s: %w\n%s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// snapshotConfigDir is the optional first install task. It archives the
+// directory of the config being installed into, which --config may move.
+func snapshotConfigDir(m *model) error {
+	dir := filepath.Dir(m.configPath)
+	meta, err := createSnapshot(dir, m.snapshotNodeModules)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot %s: %w", dir, err)
+	}
+	if err := pruneSnapshots(maxSnapshots); err != nil {
+		return err
+	}
+	if m.logFile != nil {
+		m.logFile.WriteString(fmt.Sprintf("Snapshot %s of %s (node_modules %s)\n", meta.ID, dir, meta.NodeModules))
+	}
+	return nil
+}
+
+func cmdRestoreSnapshot(args []string) error {
+	// Snapshots record their directory, so no --config here.
+	fs := flag.NewFlagSet("restore-snapshot", flag.ContinueOnError)
+	list := fs.Bool("list", false, "list snapshots instead of restoring")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *list {
+		snapshots, err := listSnapshots()
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			fmt.Println("No snapshots recorded")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCREATED\tNODE_MODULES\tPATHS")
+		for _, s := range snapshots {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.ID, s.CreatedAt.Local().Format("2006-01-02 15:04:05"), s.NodeModules, strings.Join(s.Present, ", "))
+		}
+		return w.Flush()
+	}
+
+	id := "latest"
+	if fs.NArg() > 0 {
+		id = fs.Arg(0)
+	}
+	meta, err := findSnapshot(id)
+	if err != nil {
+		return err
+	}
+
+	// Snapshot what's there now, so the restore can be undone the same way.
+	current, err := createSnapshot(meta.Dir, meta.NodeModules == "included")
+	if err != nil {
+		return fmt.Errorf("failed to snapshot current state: %w", err)
+	}
+	if err := restoreSnapshot(meta, bunInstallFromLockfile); err != nil {
+		return err
+	}
+	fmt.Printf("Restored %s from snapshot %s\n", meta.Dir, meta.ID)
+	fmt.Printf("The previous state is snapshot %s\n", current.ID)
+	return pruneSnapshots(maxSnapshots)
+}
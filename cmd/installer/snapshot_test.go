@@ -0,0 +1,154 @@
+// cmd/installer/snapshot_test.go
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSnapshotRestoresExactState(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"package.json":                `{"dependencies":{"a":"1.0.0"}}`,
+		"bun.lock":                    "lock v1",
+		"plugin/other.js":             "other",
+		"node_modules/a/package.json": `{"version":"1.0.0"}`,
+		"opencode.json":               `{}`,
+	})
+	if err := os.Symlink("/src/dist/plugin-entry.js", filepath.Join(dir, "plugin", "cursor-acp.js")); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := createSnapshot(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.NodeModules != "excluded" || meta.present("package-lock.json") {
+		t.Errorf("meta = %+v", meta)
+	}
+
+	// What an install might do.
+	writeTestFiles(t, dir, map[string]string{
+		"package.json":    `{"dependencies":{"a":"1.0.0","b":"2.0.0"}}`,
+		"plugin/extra.js": "extra",
+		"opencode.json":   `{"plugin":[]}`,
+	})
+	os.Remove(filepath.Join(dir, "bun.lock"))
+	os.Remove(filepath.Join(dir, "plugin", "cursor-acp.js"))
+
+	var installedIn string
+	err = restoreSnapshot(meta, func(d string) error {
+		installedIn = d
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"package.json":    `{"dependencies":{"a":"1.0.0"}}`,
+		"bun.lock":        "lock v1",
+		"plugin/other.js": "other",
+		"opencode.json":   `{}`,
+	} {
+		if data, _ := os.ReadFile(filepath.Join(dir, name)); string(data) != want {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+	if pathExists(filepath.Join(dir, "plugin", "extra.js")) {
+		t.Error("plugin/extra.js was added after the snapshot and should be gone")
+	}
+	if target, _ := os.Readlink(filepath.Join(dir, "plugin", "cursor-acp.js")); target != "/src/dist/plugin-entry.js" {
+		t.Errorf("cursor-acp.js -> %q", target)
+	}
+	if installedIn != dir {
+		t.Errorf("node_modules reinstall ran in %q, want %q", installedIn, dir)
+	}
+	if entries, _ := filepath.Glob(filepath.Join(dir, ".snapshot-restore-*")); len(entries) != 0 {
+		t.Errorf("staging left behind: %v", entries)
+	}
+}
+
+func TestSnapshotRemovesPathsThatDidNotExist(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+
+	meta, err := createSnapshot(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.NodeModules != "absent" || len(meta.Present) != 0 {
+		t.Errorf("meta = %+v", meta)
+	}
+
+	writeTestFiles(t, dir, map[string]string{
+		"opencode.json":               `{"plugin":["cursor-acp"]}`,
+		"package.json":                `{}`,
+		"node_modules/a/package.json": `{}`,
+		"plugin/cursor-acp.js":        "copy",
+	})
+	if err := restoreSnapshot(meta, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"opencode.json", "package.json", "node_modules", "plugin"} {
+		if pathExists(filepath.Join(dir, name)) {
+			t.Errorf("%s should have been removed", name)
+		}
+	}
+}
+
+func TestExtractSnapshotRejectsEntriesOutsideScope(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "bad.tar.gz")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "../../evil", Mode: 0644, Size: 1, Typeflag: tar.TypeReg})
+	tw.Write([]byte("x"))
+	tw.Close()
+	gz.Close()
+	f.Close()
+
+	err = extractSnapshot(archive, t.TempDir(), snapshotPaths)
+	if err == nil || !strings.Contains(err.Error(), "unexpected entry") {
+		t.Errorf("extractSnapshot = %v, want an unexpected entry error", err)
+	}
+}
+
+func TestSnapshotConfigDirFollowsConfigPath(t *testing.T) {
+	m := newTestModel(t)
+	dir := t.TempDir()
+	m.configPath = filepath.Join(dir, "opencode.json")
+	writeTestFiles(t, dir, map[string]string{"opencode.json": `{}`})
+
+	if err := snapshotConfigDir(m); err != nil {
+		t.Fatal(err)
+	}
+	snapshots, err := listSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Dir != dir || !snapshots[0].present("opencode.json") {
+		t.Errorf("snapshots = %+v, want one of %s", snapshots, dir)
+	}
+}
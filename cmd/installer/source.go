@@ -0,0 +1,396 @@
+// cmd/installer/source.go
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Install sources selectable with --from-tarball, --from-dir and --from-git,
+// for machines without registry access.
+const (
+	sourceKindTarball = "tarball"
+	sourceKindDir     = "dir"
+	sourceKindGit     = "git"
+)
+
+// installSource is an explicit plugin source replacing npm / projectDir.
+type installSource struct {
+	kind     string
+	location string // tarball path, directory or git URL
+	ref      string // git only; "" = remote default branch
+}
+
+func (s *installSource) String() string {
+	if s.ref != "" {
+		return fmt.Sprintf("%s %s#%s", s.kind, s.location, s.ref)
+	}
+	return fmt.Sprintf("%s %s", s.kind, s.location)
+}
+
+// parseInstallSource validates a --from-* flag value.
+func parseInstallSource(kind, value string) (*installSource, error) {
+	if value == "" {
+		return nil, fmt.Errorf("--from-%s requires a value", kind)
+	}
+
+	switch kind {
+	case sourceKindTarball, sourceKindDir:
+		abs, err := filepath.Abs(value)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, fmt.Errorf("--from-%s: %w", kind, err)
+		}
+		if kind == sourceKindDir && !info.IsDir() {
+			return nil, fmt.Errorf("--from-dir: %s is not a directory", value)
+		}
+		if kind == sourceKindTarball && info.IsDir() {
+			return nil, fmt.Errorf("--from-tarball: %s is a directory", value)
+		}
+		return &installSource{kind: kind, location: abs}, nil
+	case sourceKindGit:
+		url, ref, _ := strings.Cut(value, "#")
+		if url == "" {
+			return nil, fmt.Errorf("--from-git: missing repository URL")
+		}
+		return &installSource{kind: kind, location: url, ref: ref}, nil
+	}
+	return nil, fmt.Errorf("unknown install source %q", kind)
+}
+
+// getManagedDir returns the installer-owned directory for clones and
+// unpacked releases (~/.local/share/opencode-cursor).
+func getManagedDir() (string, error) {
+	dataDir, err := getDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "opencode-cursor"), nil
+}
+
+// installFromSource is the "Install plugin" task when an install source was
+// given. The result must contain a built dist/plugin-entry.js; the source is
+// recorded in the install manifest.
+func installFromSource(m *model) error {
+	src := m.installSource
+	managedDir, err := getManagedDir()
+	if err != nil {
+		return err
+	}
+
+	record := manifestSource{Kind: src.kind, Location: src.location, Ref: src.ref}
+	var dir string
+
+	switch src.kind {
+	case sourceKindTarball:
+		dir, err = extractPluginTarball(src.location, filepath.Join(managedDir, "releases"))
+		if err != nil {
+			return err
+		}
+		if err := recordChanges(manifestChange{Kind: changeDir, Path: dir}); err != nil {
+			return err
+		}
+	case sourceKindDir:
+		// The directory is used as built: building it would need registry
+		// access for bun install, which --from-dir exists to avoid.
+		dir = src.location
+		if _, err := pluginEntryPath(dir); err != nil {
+			return fmt.Errorf("%s has no built plugin - build it first (bun install && bun run build): %w", dir, err)
+		}
+	case sourceKindGit:
+		dir = filepath.Join(managedDir, "repo")
+		if err := syncManagedCheckout(m, src.location, dir); err != nil {
+			return err
+		}
+		if src.ref != "" {
+			if err := checkoutRef(m, dir, src.ref); err != nil {
+				return err
+			}
+		}
+		record.Commit = gitHeadCommit(dir)
+		if err := buildPluginFromSource(m, dir); err != nil {
+			return err
+		}
+	}
+
+	version, err := validatePluginDir(dir)
+	if err != nil {
+		return err
+	}
+	entry, err := pluginEntryPath(dir)
+	if err != nil {
+		return err
+	}
+
+	record.Version = version
+	m.pluginEntry = entry
+	return recordInstallSource(record, entry)
+}
+
+// pluginEntryPath returns dir/dist/plugin-entry.js if it exists and is
+// non-empty.
+func pluginEntryPath(dir string) (string, error) {
+	entry := filepath.Join(dir, "dist", "plugin-entry.js")
+	info, err := os.Stat(entry)
+	if err != nil || info.Size() == 0 {
+		return "", fmt.Errorf("%s not found or empty", entry)
+	}
+	return entry, nil
+}
+
+// validatePluginDir checks dir holds this plugin with a built entry point and
+// returns its version.
+func validatePluginDir(dir string) (string, error) {
+	name, version, err := readPackageIdentity(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return "", err
+	}
+	if name != npmPackage {
+		return "", fmt.Errorf("%s is %q, not %s", dir, name, npmPackage)
+	}
+	if _, err := pluginEntryPath(dir); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// extractPluginTarball unpacks an `npm pack` tarball (contents under
+// "package/") into destRoot/<version> and returns that directory.
+func extractPluginTarball(tarball, destRoot string) (string, error) {
+	if err := os.MkdirAll(destRoot, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", destRoot, err)
+	}
+	tmp, err := os.MkdirTemp(destRoot, ".extract-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	f, err := os.Open(tarball)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return "", fmt.Errorf("%s is not a gzipped tarball: %w", tarball, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", tarball, err)
+		}
+
+		// Strip the leading "package/" component npm pack adds.
+		_, rel, ok := strings.Cut(filepath.ToSlash(hdr.Name), "/")
+		if !ok || rel == "" {
+			continue
+		}
+		target := filepath.Join(tmp, filepath.FromSlash(rel))
+		if !strings.HasPrefix(target, tmp+string(filepath.Separator)) {
+			return "", fmt.Errorf("%s: entry %q escapes the archive", tarball, hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return "", err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return "", err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return "", err
+			}
+			_, copyErr := io.Copy(out, tr)
+			closeErr := out.Close()
+			if copyErr != nil {
+				return "", copyErr
+			}
+			if closeErr != nil {
+				return "", closeErr
+			}
+		}
+	}
+
+	version, err := validatePluginDir(tmp)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", filepath.Base(tarball), err)
+	}
+	// The version names the release directory, so it must not be a path.
+	if !semverRegex.MatchString(version) {
+		return "", fmt.Errorf("%s: package.json version %q is not a semver version", filepath.Base(tarball), version)
+	}
+
+	dest := filepath.Join(destRoot, version)
+	if err := os.RemoveAll(dest); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		return "", fmt.Errorf("failed to move release into place: %w", err)
+	}
+	return dest, nil
+}
+
+// syncGitCheckout clones url into dir, or points an existing clone at url
+// and fetches.
+func syncGitCheckout(m *model, url, dir string) error {
+	if !commandExists("git") {
+		return fmt.Errorf("git not found")
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return err
+		}
+		return runCommand("git clone "+url, exec.Command("git", "clone", url, dir), m.logFile)
+	}
+
+	if err := runCommand("git remote set-url origin "+url, exec.Command("git", "-C", dir, "remote", "set-url", "origin", url), m.logFile); err != nil {
+		return err
+	}
+	return runCommand("git fetch", exec.Command("git", "-C", dir, "fetch", "--tags", "--prune", "origin"), m.logFile)
+}
+
+// syncManagedCheckout is syncGitCheckout for the installer-owned clone,
+// recorded in the install manifest.
+func syncManagedCheckout(m *model, url, dir string) error {
+	existed := pathExists(dir)
+	if err := syncGitCheckout(m, url, dir); err != nil {
+		return err
+	}
+	return recordChanges(manifestChange{Kind: changeDir, Path: dir, Existed: existed})
+}
+
+// checkoutRef detaches dir at ref: a tag, a commit, or a branch (resolved
+// against origin so the fetched tip is used).
+func checkoutRef(m *model, dir, ref string) error {
+	for _, candidate := range []string{"origin/" + ref, ref} {
+		if exec.Command("git", "-C", dir, "rev-parse", "--verify", "--quiet", candidate+"^{commit}").Run() == nil {
+			return runCommand("git checkout "+candidate, exec.Command("git", "-C", dir, "checkout", "--detach", candidate), m.logFile)
+		}
+	}
+	return fmt.Errorf("ref %q not found in %s", ref, dir)
+}
+
+// gitHeadCommit returns the checked-out commit of dir, or "".
+func gitHeadCommit(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// pluginRepoURL is cloned when Build from Source isn't run from a checkout.
+const pluginRepoURL = "https://github.com/Nomadcxx/opencode-cursor.git"
+
+// isPluginCheckout reports whether dir is an open-cursor source tree rather
+// than whatever project getProjectDir happened to find.
+func isPluginCheckout(dir string) bool {
+	name, _, err := readPackageIdentity(filepath.Join(dir, "package.json"))
+	return err == nil && name == npmPackage
+}
+
+// prepareSource is the first Build from Source task. A checkout in projectDir
+// is used as is; otherwise the repo is cloned (or fetched) into the managed
+// directory and the user picks the branch, tag or commit to build.
+func prepareSource(m *model) error {
+	if isPluginCheckout(m.projectDir) {
+		return nil
+	}
+
+	managedDir, err := getManagedDir()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(managedDir, "repo")
+	if err := syncManagedCheckout(m, pluginRepoURL, dir); err != nil {
+		return err
+	}
+
+	choices := listGitRefs(dir)
+	if len(choices) == 0 {
+		return fmt.Errorf("no branches or tags found in %s", dir)
+	}
+
+	m.prompt = &taskPrompt{
+		title:   "Choose what to build",
+		body:    []string{fmt.Sprintf("%s is not an open-cursor checkout; cloned %s into %s.", m.projectDir, pluginRepoURL, dir)},
+		choices: choices,
+		options: []promptOption{
+			{key: "enter", label: "Check out and build", apply: func(m *model, choice string) error {
+				ref := strings.Fields(choice)[0]
+				if err := checkoutRef(m, dir, ref); err != nil {
+					return err
+				}
+				m.projectDir = dir
+				m.sourceRef = ref
+				m.sourceCommit = gitHeadCommit(dir)
+				return nil
+			}},
+			{key: "c", label: "Cancel", apply: func(m *model, _ string) error {
+				return fmt.Errorf("no ref selected")
+			}},
+		},
+	}
+	return nil
+}
+
+// listGitRefs returns pick-list entries for a clone, the ref first: the
+// default branch, tags newest first, other branches, then recent commits on
+// the default branch.
+func listGitRefs(dir string) []string {
+	lines := func(args ...string) []string {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+		if err != nil {
+			return nil
+		}
+		var result []string
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				result = append(result, line)
+			}
+		}
+		return result
+	}
+
+	var choices []string
+	defaultBranch := ""
+	if head := lines("symbolic-ref", "--short", "refs/remotes/origin/HEAD"); len(head) == 1 {
+		defaultBranch = strings.TrimPrefix(head[0], "origin/")
+		choices = append(choices, defaultBranch+"  (default branch)")
+	}
+	for _, tag := range lines("tag", "--sort=-v:refname") {
+		choices = append(choices, tag+"  (tag)")
+	}
+	for _, branch := range lines("branch", "-r", "--format=%(refname:short)") {
+		branch = strings.TrimPrefix(branch, "origin/")
+		if branch == defaultBranch || branch == "HEAD" || branch == "origin" {
+			continue
+		}
+		choices = append(choices, branch+"  (branch)")
+	}
+	if defaultBranch != "" {
+		for _, commit := range lines("log", "-n", "10", "--format=%h  %s", "origin/"+defaultBranch) {
+			choices = append(choices, commit)
+		}
+	}
+	return choices
+}
@@ -0,0 +1,175 @@
+// cmd/installer/source_test.go
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTarball(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractPluginTarball(t *testing.T) {
+	dir := t.TempDir()
+	tarball := filepath.Join(dir, "open-cursor-2.3.10.tgz")
+	writeTarball(t, tarball, map[string]string{
+		"package/package.json":         `{"name": "` + npmPackage + `", "version": "2.3.10"}`,
+		"package/dist/plugin-entry.js": "export default {}\n",
+		"package/src/plugin-entry.ts":  "export default {}\n",
+	})
+
+	dest, err := extractPluginTarball(tarball, filepath.Join(dir, "releases"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(dest) != "2.3.10" {
+		t.Errorf("dest = %s, want releases/2.3.10", dest)
+	}
+	if _, err := pluginEntryPath(dest); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExtractPluginTarballRejectsOtherPackages(t *testing.T) {
+	dir := t.TempDir()
+	tarball := filepath.Join(dir, "other.tgz")
+	writeTarball(t, tarball, map[string]string{
+		"package/package.json":         `{"name": "other", "version": "1.0.0"}`,
+		"package/dist/plugin-entry.js": "export default {}\n",
+	})
+
+	_, err := extractPluginTarball(tarball, filepath.Join(dir, "releases"))
+	if err == nil || !strings.Contains(err.Error(), `"other"`) {
+		t.Errorf("err = %v, want package name mismatch", err)
+	}
+}
+
+func TestExtractPluginTarballRequiresBuiltEntry(t *testing.T) {
+	dir := t.TempDir()
+	tarball := filepath.Join(dir, "unbuilt.tgz")
+	writeTarball(t, tarball, map[string]string{
+		"package/package.json": `{"name": "` + npmPackage + `", "version": "2.3.10"}`,
+	})
+
+	if _, err := extractPluginTarball(tarball, filepath.Join(dir, "releases")); err == nil {
+		t.Error("expected an error for a tarball without dist/plugin-entry.js")
+	}
+}
+
+func TestExtractPluginTarballRejectsBadVersions(t *testing.T) {
+	dir := t.TempDir()
+	for _, version := range []string{"../../escaped", "2.3.10/x"} {
+		tarball := filepath.Join(dir, "bad.tgz")
+		writeTarball(t, tarball, map[string]string{
+			"package/package.json":         `{"name": "` + npmPackage + `", "version": "` + version + `"}`,
+			"package/dist/plugin-entry.js": "export default {}\n",
+		})
+
+		_, err := extractPluginTarball(tarball, filepath.Join(dir, "releases"))
+		if err == nil || !strings.Contains(err.Error(), "not a semver version") {
+			t.Errorf("version %q: err = %v, want semver error", version, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escaped")); err == nil {
+		t.Error("release escaped the releases directory")
+	}
+}
+
+func TestInstallFromDirRequiresBuild(t *testing.T) {
+	m := newTestModel(t)
+	dir := t.TempDir()
+	pkg := `{"name": "` + npmPackage + `", "version": "2.3.10"}`
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(pkg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m.installSource = &installSource{kind: sourceKindDir, location: dir}
+
+	err := installFromSource(m)
+	if err == nil || !strings.Contains(err.Error(), "build it first") {
+		t.Errorf("err = %v, want a build it first error", err)
+	}
+}
+
+func TestParseInstallSourceGit(t *testing.T) {
+	src, err := parseInstallSource(sourceKindGit, "/srv/mirror/opencode-cursor.git#v2.3.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.location != "/srv/mirror/opencode-cursor.git" || src.ref != "v2.3.10" {
+		t.Errorf("source = %+v", src)
+	}
+	if _, err := parseInstallSource(sourceKindGit, "#main"); err == nil {
+		t.Error("accepted a git source without URL")
+	}
+}
+
+func TestPrepareSourceClonesAndListsRefs(t *testing.T) {
+	if !commandExists("git") {
+		t.Skip("git not installed")
+	}
+	upstream := t.TempDir()
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git(upstream, "init", "-q", "-b", "main")
+	pkg := `{"name": "` + npmPackage + `", "version": "2.3.10"}`
+	if err := os.WriteFile(filepath.Join(upstream, "package.json"), []byte(pkg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(upstream, "add", ".")
+	git(upstream, "commit", "-q", "-m", "initial")
+	git(upstream, "tag", "v2.3.10")
+
+	dir := filepath.Join(t.TempDir(), "repo")
+	m := &model{}
+	if err := syncGitCheckout(m, upstream, dir); err != nil {
+		t.Fatal(err)
+	}
+	if !isPluginCheckout(dir) {
+		t.Fatal("clone not recognised as a plugin checkout")
+	}
+
+	choices := listGitRefs(dir)
+	if len(choices) < 3 || choices[0] != "main  (default branch)" || choices[1] != "v2.3.10  (tag)" {
+		t.Fatalf("choices = %q", choices)
+	}
+
+	if err := checkoutRef(m, dir, "v2.3.10"); err != nil {
+		t.Fatal(err)
+	}
+	if gitHeadCommit(dir) == "" {
+		t.Error("no commit after checkout")
+	}
+}
@@ -0,0 +1,259 @@
+// cmd/installer/status.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+// proxyTimeout bounds the reachability check against options.baseURL.
+const proxyTimeout = 2 * time.Second
+
+// installStatus is what `installer status` reports. Field names are part of
+// the --json output that inventory scripts read; add fields, don't rename.
+// Top-level fields are always present, empty or null when unknown.
+type installStatus struct {
+	ConfigPath string `json:"configPath"`
+	Configured bool   `json:"configured"`
+	Disabled   bool   `json:"disabled"`
+
+	// PluginMode is how OpenCode loads the plugin: "npm" from the plugin
+	// array, or the cursor-acp.js link mode (symlink, relative, copy).
+	PluginMode    string `json:"pluginMode"`
+	PluginSpec    string `json:"pluginSpec"`
+	PluginTarget  string `json:"pluginTarget"`
+	Version       string `json:"version"`
+	VersionSource string `json:"versionSource"`
+
+	Models  int    `json:"models"`
+	BaseURL string `json:"baseURL"`
+
+	OpenCode    openCodeStatus    `json:"opencode"`
+	CursorAgent cursorAgentStatus `json:"cursorAgent"`
+	Bun         bool              `json:"bun"`
+	LastSync    *syncState        `json:"lastSync"`
+	Proxy       *proxyStatus      `json:"proxy"`
+}
+
+type openCodeStatus struct {
+	Installed     bool   `json:"installed"`
+	Version       string `json:"version"`
+	Binary        string `json:"binary,omitempty"`
+	InstallMethod string `json:"installMethod,omitempty"`
+}
+
+type cursorAgentStatus struct {
+	Installed bool `json:"installed"`
+	LoggedIn  bool `json:"loggedIn"`
+}
+
+// proxyStatus is whether the plugin's proxy answered at baseURL. It only
+// listens while OpenCode is running.
+type proxyStatus struct {
+	URL       string `json:"url"`
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+}
+
+// collectStatus gathers the installation's state for configPath.
+func collectStatus(m *model) installStatus {
+	s := installStatus{ConfigPath: m.configPath, Disabled: isPluginDisabled(), Bun: commandExists("bun")}
+
+	config, err := opencodeconfig.Load(m.configPath)
+	if err == nil {
+		if p := config.CursorACP(); p != nil {
+			s.Configured = true
+			s.Models = len(p.ModelIDs())
+			if p.Options != nil {
+				s.BaseURL = p.Options.BaseURL
+			}
+		}
+		for _, i := range config.CursorACPVariants() {
+			if s.PluginSpec == "" && config.Plugin[i].IsString() {
+				s.PluginSpec = config.Plugin[i].Spec
+			}
+		}
+	}
+
+	pluginFile := pluginFilePath(m.pluginDir)
+	if mode := detectLinkMode(pluginFile); mode != "" {
+		s.PluginMode = mode
+		if mode == linkModeCopy {
+			if mf, err := loadInstallManifest(); err == nil {
+				s.PluginTarget = mf.PluginEntry
+			}
+		} else if target, err := filepath.EvalSymlinks(pluginFile); err == nil {
+			s.PluginTarget = target
+		} else {
+			target, _ := os.Readlink(pluginFile)
+			s.PluginTarget = target + " (dangling)"
+		}
+	} else if strings.HasPrefix(s.PluginSpec, npmPackage) {
+		s.PluginMode = "npm"
+	}
+
+	installed := detectInstalledPlugin(m)
+	if installed.source != sourceUnknown {
+		s.Version = installed.version
+		s.VersionSource = installed.source.String()
+		if s.PluginMode == "npm" {
+			s.PluginTarget = installed.dir
+		}
+	}
+
+	oc := detectOpenCodeInstall()
+	s.OpenCode = openCodeStatus{Installed: oc.Installed, Version: oc.Version, Binary: oc.BinaryPath}
+	if oc.Installed {
+		s.OpenCode.InstallMethod = oc.InstallMethod.String()
+	}
+
+	if commandExists("cursor-agent") {
+		s.CursorAgent = cursorAgentStatus{Installed: true, LoggedIn: cursorAgentLoggedIn()}
+	}
+
+	if state, err := loadSyncState(); err == nil {
+		s.LastSync = state
+	}
+
+	if s.Configured {
+		baseURL := s.BaseURL
+		if baseURL == "" {
+			baseURL = opencodeconfig.DefaultBaseURL
+		}
+		s.Proxy = checkProxy(baseURL)
+	}
+	return s
+}
+
+// loadSyncState reads last-sync.json, written by recordSyncState.
+func loadSyncState() (*syncState, error) {
+	stateDir, err := getStateDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(stateDir, "last-sync.json"))
+	if err != nil {
+		return nil, err
+	}
+	var state syncState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// checkProxy asks the proxy for its model list. Any HTTP answer counts as
+// reachable; only a connection failure doesn't.
+func checkProxy(baseURL string) *proxyStatus {
+	status := &proxyStatus{URL: baseURL}
+	client := &http.Client{Timeout: proxyTimeout}
+	resp, err := client.Get(strings.TrimRight(baseURL, "/") + "/models")
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	resp.Body.Close()
+	status.Reachable = true
+	return status
+}
+
+func printStatus(w io.Writer, s installStatus) error {
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+	orNone := func(v string) string {
+		if v == "" {
+			return "-"
+		}
+		return v
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	row := func(key, value string) { fmt.Fprintf(tw, "%s\t%s\n", key, value) }
+
+	row("Config", s.ConfigPath)
+	switch {
+	case s.Disabled:
+		row("cursor-acp", "disabled (run `installer enable`)")
+	case s.Configured:
+		row("cursor-acp", "configured")
+	default:
+		row("cursor-acp", "not configured")
+	}
+	row("Plugin mode", orNone(s.PluginMode))
+	row("Plugin entry", orNone(s.PluginSpec))
+	row("Plugin target", orNone(s.PluginTarget))
+	version := orNone(s.Version)
+	if s.VersionSource != "" {
+		version += " (" + s.VersionSource + ")"
+	}
+	row("Version", version)
+	row("Models", fmt.Sprintf("%d", s.Models))
+	row("baseURL", orNone(s.BaseURL))
+
+	openCode := "not found"
+	if s.OpenCode.Installed {
+		openCode = fmt.Sprintf("%s (%s) at %s", orNone(s.OpenCode.Version), s.OpenCode.InstallMethod, s.OpenCode.Binary)
+	}
+	row("OpenCode", openCode)
+
+	agent := "not found"
+	if s.CursorAgent.Installed {
+		agent = "installed, logged in: " + yesNo(s.CursorAgent.LoggedIn)
+	}
+	row("cursor-agent", agent)
+	row("bun", yesNo(s.Bun))
+
+	lastSync := "never"
+	if s.LastSync != nil {
+		lastSync = fmt.Sprintf("%s, %d models", s.LastSync.Time.Local().Format("2006-01-02 15:04"), s.LastSync.Models)
+		if s.LastSync.Error != "" {
+			lastSync = s.LastSync.Time.Local().Format("2006-01-02 15:04") + ", failed: " + s.LastSync.Error
+		}
+	}
+	row("Last sync", lastSync)
+
+	proxy := "-"
+	if s.Proxy != nil {
+		proxy = s.Proxy.URL + " reachable"
+		if !s.Proxy.Reachable {
+			proxy = s.Proxy.URL + " not reachable (is OpenCode running?)"
+		}
+	}
+	row("Proxy", proxy)
+	return tw.Flush()
+}
+
+func cmdStatus(args []string) error {
+	fs, configPath := newFlagSet("status")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	m := newHeadlessModel(*configPath)
+	defer m.cancel()
+	s := collectStatus(&m)
+
+	if *asJSON {
+		data, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	return printStatus(os.Stdout, s)
+}
@@ -0,0 +1,75 @@
+// cmd/installer/status_test.go
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectStatus(t *testing.T) {
+	m := newTestModel(t)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer proxy.Close()
+
+	writeTestConfig(t, m.configPath, `{
+		"plugin": ["cursor-acp"],
+		"provider": {"cursor-acp": {"options": {"baseURL": "`+proxy.URL+`/v1"}, "models": {"auto": {}, "gpt-5": {}}}}
+	}`)
+	entry := writePluginEntry(t, m.projectDir)
+	if err := linkPluginFile(entry, pluginFilePath(m.pluginDir), linkModeSymlink); err != nil {
+		t.Fatal(err)
+	}
+	synced := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := recordSyncState(syncState{Time: synced, Models: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := collectStatus(m)
+	if !s.Configured || s.Models != 2 || s.PluginSpec != "cursor-acp" {
+		t.Errorf("config fields = %+v", s)
+	}
+	if s.PluginMode != linkModeSymlink {
+		t.Errorf("PluginMode = %q", s.PluginMode)
+	}
+	if resolved, _ := filepath.EvalSymlinks(entry); s.PluginTarget != resolved {
+		t.Errorf("PluginTarget = %q, want %q", s.PluginTarget, resolved)
+	}
+	if s.LastSync == nil || !s.LastSync.Time.Equal(synced) {
+		t.Errorf("LastSync = %+v", s.LastSync)
+	}
+	if s.Proxy == nil || !s.Proxy.Reachable {
+		t.Errorf("Proxy = %+v", s.Proxy)
+	}
+
+	var out bytes.Buffer
+	if err := printStatus(&out, s); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), proxy.URL+"/v1 reachable") {
+		t.Errorf("table output:\n%s", out.String())
+	}
+}
+
+func TestCollectStatusDanglingLink(t *testing.T) {
+	m := newTestModel(t)
+	writeTestConfig(t, m.configPath, `{"plugin": ["cursor-acp"]}`)
+	if err := os.MkdirAll(m.pluginDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/gone/dist/plugin-entry.js", pluginFilePath(m.pluginDir)); err != nil {
+		t.Fatal(err)
+	}
+
+	s := collectStatus(m)
+	if s.PluginTarget != "/gone/dist/plugin-entry.js (dangling)" || s.Proxy != nil {
+		t.Errorf("status = %+v", s)
+	}
+}
@@ -9,50 +9,25 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
 )
 
-const npmPackage = "@rama_nigg/open-cursor"
+const npmPackage = opencodeconfig.NPMPackage
 
-func parseCursorModelsOutput(clean string) (map[string]interface{}, error) {
-	// More permissive regex: allows uppercase, underscores, and various separators
-	// Pattern: model-id followed by separator and display name
-	lineRegex := regexp.MustCompile(`^([a-zA-Z0-9._-]+)\s+[-–—:]\s+(.+?)(?:\s+\((current|default)\))*\s*$`)
-	models := make(map[string]interface{})
-
-	lines := strings.Split(clean, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "Available") || strings.HasPrefix(line, "Tip:") {
-			continue
-		}
-		matches := lineRegex.FindStringSubmatch(line)
-		if len(matches) >= 3 {
-			id := matches[1]
-			name := strings.TrimSpace(matches[2])
-			models[id] = map[string]interface{}{"name": name}
-		}
-	}
-
-	if len(models) == 0 {
-		return nil, fmt.Errorf("regex matched 0 of %d lines", len(lines))
-	}
-
-	return models, nil
-}
-
-// fetchCursorModels calls cursor-agent models and parses the output
-func fetchCursorModels() (map[string]interface{}, error) {
+// fetchCursorModels calls cursor-agent models and parses the output.
+// The JSON output mode is tried first; releases that don't support it either
+// fail the command or print text, which the text parsers pick up.
+func fetchCursorModels() (map[string]string, error) {
 	variants := [][]string{
+		{"models", "--output-format", "json"},
 		{"models"},
 		{"--list", "models"},
 	}
 
-	ansiRegex := regexp.MustCompile(`\x1b\[[0-9;]*[a-zA-Z]`)
 	var lastErr error
 	var lastClean string
 
@@ -71,7 +46,7 @@ func fetchCursorModels() (map[string]interface{}, error) {
 			continue
 		}
 
-		clean := ansiRegex.ReplaceAllString(string(output), "")
+		clean := stripANSI(string(output))
 		lastClean = clean
 
 		models, parseErr := parseCursorModelsOutput(clean)
@@ -113,14 +88,41 @@ func isMissingModuleBuildError(err error) bool {
 func (m model) startInstallation() (tea.Model, tea.Cmd) {
 	m.step = stepInstalling
 
-	m.tasks = []installTask{
+	source := []installTask{
+		{name: "Prepare source", description: "Checking for an open-cursor checkout", execute: prepareSource, status: statusPending},
+		{name: "Build plugin", description: "bun install && bun run build", execute: buildPlugin, status: statusPending},
+	}
+	if m.installSource != nil {
+		source = []installTask{
+			{name: "Install plugin", description: "Installing from " + m.installSource.String(), execute: installFromSource, status: statusPending},
+		}
+	} else if isPluginCheckout(m.projectDir) {
+		m.sourceCommit = gitHeadCommit(m.projectDir)
+	}
+
+	m.tasks = append([]installTask{
 		{name: "Check prerequisites", description: "Verifying bun and cursor-agent", execute: checkPrerequisites, status: statusPending},
-		{name: "Install plugin", description: "npm (preferred) or bun build fallback", execute: buildPlugin, status: statusPending},
+	}, source...)
+	m.tasks = append(m.tasks, []installTask{
 		{name: "Install AI SDK", description: "Adding @ai-sdk/openai-compatible to opencode", execute: installAiSdk, status: statusPending},
 		{name: "Create symlink", description: "Linking to OpenCode plugin directory", execute: createSymlink, status: statusPending},
 		{name: "Update config", description: "Adding cursor-acp plugin to opencode.json", execute: updateConfig, status: statusPending},
+		{name: "Normalize plugin entries", description: "Checking for conflicting plugin entries", execute: normalizePluginEntries, optional: true, status: statusPending},
 		{name: "Validate config", description: "Checking JSON syntax", execute: validateConfig, status: statusPending},
 		{name: "Verify plugin loads", description: "Checking if plugin appears in opencode", execute: verifyPostInstall, optional: true, status: statusPending},
+	}...)
+	if m.chooseDefaults {
+		m.tasks = insertTaskAfter(m.tasks, "Validate config", chooseDefaultsTask())
+	}
+	if m.probeModels {
+		m.tasks = insertTaskAfter(m.tasks, "Update config", probeModelsTask())
+	}
+	if m.runTests && m.installSource == nil {
+		m.tasks = insertTaskAfter(m.tasks, "Build plugin", installTask{name: "Run tests", description: "bun run " + unitTestScript, execute: runPluginTests, status: statusPending})
+	}
+	m.tasks = insertTaskAfter(m.tasks, "Check prerequisites", migrationsTask(false))
+	if m.snapshot {
+		m.tasks = insertTaskAfter(m.tasks, "Check prerequisites", snapshotTask())
 	}
 
 	m.currentTaskIndex = 0
@@ -135,15 +137,50 @@ func (m model) startQuickInstallation() (tea.Model, tea.Cmd) {
 		{name: "Check prerequisites", description: "Verifying bun and cursor-agent", execute: checkQuickPrereqs, status: statusPending},
 		{name: "Install AI SDK", description: "Adding @ai-sdk/openai-compatible to opencode", execute: installAiSdk, status: statusPending},
 		{name: "Update config", description: "Adding npm package to opencode.json", execute: updateConfigQuick, status: statusPending},
+		{name: "Normalize plugin entries", description: "Checking for conflicting plugin entries", execute: normalizePluginEntries, optional: true, status: statusPending},
 		{name: "Fetch models", description: "Fetching models from cursor-agent", execute: fetchAndAddModels, status: statusPending},
 		{name: "Verify plugin loads", description: "Checking if plugin appears in opencode", execute: verifyPostInstall, optional: true, status: statusPending},
 	}
+	if m.chooseDefaults {
+		m.tasks = insertTaskAfter(m.tasks, "Fetch models", chooseDefaultsTask())
+	}
+	if m.probeModels {
+		m.tasks = insertTaskAfter(m.tasks, "Fetch models", probeModelsTask())
+	}
+	m.tasks = insertTaskAfter(m.tasks, "Check prerequisites", migrationsTask(false))
+	if m.snapshot {
+		m.tasks = insertTaskAfter(m.tasks, "Check prerequisites", snapshotTask())
+	}
 
 	m.currentTaskIndex = 0
 	m.tasks[0].status = statusRunning
 	return m, tea.Batch(m.spinner.Tick, executeTaskCmd(0, &m))
 }
 
+func probeModelsTask() installTask {
+	return installTask{name: "Probe models", description: "Sending a test request to each model", execute: probeConfiguredModels, optional: true, status: statusPending}
+}
+
+func chooseDefaultsTask() installTask {
+	return installTask{name: "Choose default models", description: "Selecting model and small_model", execute: chooseDefaultModels, optional: true, status: statusPending}
+}
+
+func snapshotTask() installTask {
+	return installTask{name: "Snapshot config dir", description: "Archiving package.json, lockfile and plugin/", execute: snapshotConfigDir, status: statusPending}
+}
+
+// insertTaskAfter returns tasks with extra placed after the task named after.
+func insertTaskAfter(tasks []installTask, after string, extra installTask) []installTask {
+	for i, t := range tasks {
+		if t.name == after {
+			out := append([]installTask{}, tasks[:i+1]...)
+			out = append(out, extra)
+			return append(out, tasks[i+1:]...)
+		}
+	}
+	return append(tasks, extra)
+}
+
 func executeTaskCmd(index int, m *model) tea.Cmd {
 	return func() tea.Msg {
 		if index >= len(m.tasks) {
@@ -151,6 +188,8 @@ func executeTaskCmd(index int, m *model) tea.Cmd {
 		}
 
 		task := &m.tasks[index]
+		m.prompt = nil
+		m.skipTasks = nil
 		err := task.execute(m)
 
 		if err != nil {
@@ -161,7 +200,7 @@ func executeTaskCmd(index int, m *model) tea.Cmd {
 			}
 		}
 
-		return taskCompleteMsg{index: index, success: true}
+		return taskCompleteMsg{index: index, success: true, prompt: m.prompt, skip: m.skipTasks}
 	}
 }
 
@@ -194,38 +233,34 @@ func checkQuickPrereqs(m *model) error {
 	return nil
 }
 
+// buildPlugin builds the open-cursor checkout in m.projectDir, which
+// prepareSource has verified or cloned.
 func buildPlugin(m *model) error {
-	// Prefer npm-installed package when available; fall back to local build.
-	if commandExists("npm") {
-		installCmd := exec.Command("npm", "install", "-g", fmt.Sprintf("%s@%s", npmPackage, m.npmTag))
-		if err := runCommand(fmt.Sprintf("npm install -g %s@%s", npmPackage, m.npmTag), installCmd, m.logFile); err == nil {
-			rootCmd := exec.Command("npm", "root", "-g")
-			rootOut, rootErr := rootCmd.Output()
-			if rootErr == nil {
-				root := strings.TrimSpace(string(rootOut))
-				entry := filepath.Join(root, "@rama_nigg", "open-cursor", "dist", "plugin-entry.js")
-				if info, err := os.Stat(entry); err == nil && info.Size() > 0 {
-					m.pluginEntry = entry
-					return nil
-				}
-			}
-		}
-		// If npm install failed, continue to bun fallback; log only in debug mode.
-		if m.debugMode && m.logFile != nil {
-			m.logFile.WriteString("npm install @rama_nigg/open-cursor failed or plugin entry not found; falling back to bun build\n")
-		}
+	if err := buildPluginFromSource(m, m.projectDir); err != nil {
+		return err
 	}
+	version, _ := readPackageVersion(filepath.Join(m.projectDir, "package.json"))
+	return recordInstallSource(manifestSource{
+		Kind:     "build",
+		Location: m.projectDir,
+		Commit:   gitHeadCommit(m.projectDir),
+		Version:  version,
+	}, m.pluginEntry)
+}
 
+// buildPluginFromSource runs bun install and bun run build in dir and points
+// m.pluginEntry at the resulting dist/plugin-entry.js.
+func buildPluginFromSource(m *model, dir string) error {
 	// Run bun install
 	installCmd := exec.Command("bun", "install")
-	installCmd.Dir = m.projectDir
+	installCmd.Dir = dir
 	if err := runCommand("bun install", installCmd, m.logFile); err != nil {
 		return err
 	}
 
 	// Run bun run build
 	buildCmd := exec.Command("bun", "run", "build")
-	buildCmd.Dir = m.projectDir
+	buildCmd.Dir = dir
 	if err := runCommand("bun run build", buildCmd, m.logFile); err != nil {
 		if !isMissingModuleBuildError(err) {
 			return err
@@ -233,20 +268,20 @@ func buildPlugin(m *model) error {
 
 		// Recovery path for stale/broken node_modules where bun install did not restore all packages.
 		repairCmd := exec.Command("bun", "install", "--force", "--no-cache")
-		repairCmd.Dir = m.projectDir
+		repairCmd.Dir = dir
 		if repairErr := runCommand("bun install --force --no-cache", repairCmd, m.logFile); repairErr != nil {
 			return repairErr
 		}
 
 		retryBuildCmd := exec.Command("bun", "run", "build")
-		retryBuildCmd.Dir = m.projectDir
+		retryBuildCmd.Dir = dir
 		if retryErr := runCommand("bun run build (retry)", retryBuildCmd, m.logFile); retryErr != nil {
 			return retryErr
 		}
 	}
 
 	// Verify dist/plugin-entry.js exists (plugin-only entrypoint)
-	distPath := filepath.Join(m.projectDir, "dist", "plugin-entry.js")
+	distPath := filepath.Join(dir, "dist", "plugin-entry.js")
 	info, err := os.Stat(distPath)
 	if err != nil || info.Size() == 0 {
 		return fmt.Errorf("dist/plugin-entry.js not found or empty after build")
@@ -268,64 +303,37 @@ func installAiSdk(m *model) error {
 		return NewConfigError("failed to create opencode directory", opencodeDir, err)
 	}
 
+	existed := pathExists(filepath.Join(opencodeDir, "node_modules", "@ai-sdk", "openai-compatible"))
+
 	installCmd := exec.Command("bun", "install", "@ai-sdk/openai-compatible")
 	installCmd.Dir = opencodeDir
 	if err := runCommand("bun install @ai-sdk/openai-compatible", installCmd, m.logFile); err != nil {
 		return err
 	}
 
-	return nil
-}
-
-func installAcpSdk(m *model) error {
-	if err := createBackup(m, m.configPath); err != nil {
-		return fmt.Errorf("failed to backup config: %w", err)
-	}
-
-	configDir, _ := getConfigDir()
-	opencodeNodeModules := filepath.Join(configDir, "opencode", "node_modules")
-
-	acpPath := filepath.Join(opencodeNodeModules, "@agentclientprotocol", "sdk")
-	if _, err := os.Stat(acpPath); err == nil {
-		return nil
-	}
-
-	packageJsonPath := filepath.Join(configDir, "opencode", "package.json")
-	if err := createBackup(m, packageJsonPath); err != nil {
-		return fmt.Errorf("failed to backup package.json: %w", err)
-	}
-
-	installCmd := exec.Command("bun", "add", "@agentclientprotocol/sdk@^0.13.1")
-	installCmd.Dir = filepath.Join(configDir, "opencode")
-	if err := runCommand("bun add @agentclientprotocol/sdk", installCmd, m.logFile); err != nil {
-		cleanupBackups(m)
-		return fmt.Errorf("failed to install ACP SDK: %w", err)
-	}
-
-	return nil
+	return recordChanges(manifestChange{Kind: changePackage, Path: opencodeDir, Key: "@ai-sdk/openai-compatible", Via: "bun", Existed: existed})
 }
 
+// createSymlink links (or, in copy mode, copies) the plugin entry into
+// OpenCode's plugin directory as cursor-acp.js.
 func createSymlink(m *model) error {
-	// Ensure plugin directory exists (e.g. ~/.config/opencode/plugin)
-	if err := os.MkdirAll(m.pluginDir, 0755); err != nil {
-		return fmt.Errorf("failed to create plugin directory: %w", err)
-	}
-
-	// Create symlink in OpenCode's plugin directory
-	symlinkPath := filepath.Join(m.pluginDir, "cursor-acp.js")
-
-	// Remove existing symlink if present
-	if _, err := os.Lstat(symlinkPath); err == nil {
-		os.Remove(symlinkPath)
-	}
+	symlinkPath := pluginFilePath(m.pluginDir)
 
-	// Create symlink to plugin entry (npm path preferred, fallback to local dist)
+	// Link to plugin entry (npm path preferred, fallback to local
+	// dist). The install task records the entry in the manifest.
 	entry := m.pluginEntry
+	if entry == "" {
+		if mf, err := loadInstallManifest(); err == nil {
+			entry = mf.PluginEntry
+		}
+	}
 	if entry == "" {
 		entry = filepath.Join(m.projectDir, "dist", "plugin-entry.js")
 	}
-	if err := os.Symlink(entry, symlinkPath); err != nil {
-		return fmt.Errorf("failed to create symlink: %w", err)
+	existed := pathExists(symlinkPath)
+	priorTarget, _ := os.Readlink(symlinkPath)
+	if err := linkPluginFile(entry, symlinkPath, m.linkMode); err != nil {
+		return err
 	}
 
 	// Verify symlink resolves
@@ -333,36 +341,28 @@ func createSymlink(m *model) error {
 		return fmt.Errorf("symlink verification failed: %w", err)
 	}
 
-	return nil
+	change, err := pluginFileChange(symlinkPath, existed, priorTarget)
+	if err != nil {
+		return err
+	}
+	if err := recordChanges(change); err != nil {
+		return err
+	}
+	return recordLinkMode(m.linkMode)
 }
 
 func updateConfig(m *model) error {
 	// Persist a timestamped backup for recovery outside the installer process
-	_ = backupConfigToDisk(m.configPath)
+	_ = backupConfigToDisk(m.configPath, m.currentTaskName())
 	if err := createBackup(m, m.configPath); err != nil {
 		return fmt.Errorf("failed to backup config: %w", err)
 	}
 
-	var config map[string]interface{}
-
-	data, err := os.ReadFile(m.configPath)
+	config, err := opencodeconfig.LoadOrEmpty(m.configPath)
 	if err != nil {
-		if !os.IsNotExist(err) {
-			return fmt.Errorf("failed to read config: %w", err)
-		}
-		config = make(map[string]interface{})
-	} else {
-		if err := json.Unmarshal(data, &config); err != nil {
-			return fmt.Errorf("failed to parse config: %w", err)
-		}
-	}
-
-	// Ensure provider section exists
-	providers, ok := config["provider"].(map[string]interface{})
-	if !ok {
-		providers = make(map[string]interface{})
-		config["provider"] = providers
+		return fmt.Errorf("failed to read config: %w", err)
 	}
+	before := config.Clone()
 
 	// Fetch models dynamically from cursor-agent
 	models, err := fetchCursorModels()
@@ -370,219 +370,119 @@ func updateConfig(m *model) error {
 		return fmt.Errorf("failed to fetch models from cursor-agent: %w", err)
 	}
 
-	// Add cursor-acp provider (merge with existing to preserve user config)
-	existingCursorAcp, ok := providers["cursor-acp"].(map[string]interface{})
-	if !ok {
-		// If cursor-acp exists but isn't a map, user config is malformed
-		if providers["cursor-acp"] != nil {
-			return fmt.Errorf("cursor-acp provider has invalid type (expected object, got %T)", providers["cursor-acp"])
-		}
-		existingCursorAcp = make(map[string]interface{})
-	}
-
-	// Only set name if not already present (preserve user customization)
-	if _, hasName := existingCursorAcp["name"]; !hasName {
-		existingCursorAcp["name"] = "Cursor Agent (ACP stdin)"
-	}
-
-	// Always update models list (this is what installer needs to ensure)
-	existingCursorAcp["models"] = models
-
-	// Ensure options.baseURL is set so OpenCode never builds "undefined/chat/completions"
-	const defaultBaseURL = "http://127.0.0.1:32124/v1"
-	opts, _ := existingCursorAcp["options"].(map[string]interface{})
-	if opts == nil {
-		opts = make(map[string]interface{})
-		existingCursorAcp["options"] = opts
-	}
-	if _, hasBaseURL := opts["baseURL"]; !hasBaseURL {
-		opts["baseURL"] = defaultBaseURL
-	}
-
-	// Preserve any other user fields (npm, etc.)
-	providers["cursor-acp"] = existingCursorAcp
-
-	// Ensure plugin array exists and add cursor-acp
-	plugins, ok := config["plugin"].([]interface{})
-	if !ok {
-		plugins = []interface{}{}
-	}
-
-	// Check if cursor-acp is already in the plugin list
-	hasPlugin := false
-	for _, p := range plugins {
-		if p == "cursor-acp" {
-			hasPlugin = true
-			break
-		}
-	}
-
-	if !hasPlugin {
-		plugins = append(plugins, "cursor-acp")
-		config["plugin"] = plugins
-	}
-
-	// Write config back
-	output, err := json.MarshalIndent(config, "", "  ")
+	// Add cursor-acp provider (merge with existing to preserve user config);
+	// options.baseURL is always set so OpenCode never builds
+	// "undefined/chat/completions".
+	provider, err := config.EnsureCursorACP()
 	if err != nil {
-		return fmt.Errorf("failed to serialize config: %w", err)
+		return NewConfigError("cannot add the cursor-acp provider", m.configPath, err)
 	}
 
-	// Ensure config directory exists
-	if err := os.MkdirAll(filepath.Dir(m.configPath), 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
-	}
+	// Always update models list (this is what installer needs to ensure)
+	provider.SetModels(models)
 
-	if err := os.WriteFile(m.configPath, output, 0644); err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
+	if !config.HasPlugin(opencodeconfig.PluginEntry.IsCursorACP) {
+		config.AddPlugin(preferredPluginSpec(m))
 	}
 
-	return nil
+	return saveTrackedConfig(m.configPath, before, config)
 }
 
 func updateConfigQuick(m *model) error {
-	_ = backupConfigToDisk(m.configPath)
+	_ = backupConfigToDisk(m.configPath, m.currentTaskName())
 	if err := createBackup(m, m.configPath); err != nil {
 		return fmt.Errorf("failed to backup config: %w", err)
 	}
 
-	data, err := os.ReadFile(m.configPath)
+	config, err := opencodeconfig.Load(m.configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return fmt.Errorf("opencode config not found: %s", m.configPath)
 		}
 		return fmt.Errorf("failed to read config: %w", err)
 	}
+	before := config.Clone()
 
-	var config map[string]interface{}
-	if err := json.Unmarshal(data, &config); err != nil {
-		return fmt.Errorf("failed to parse config: %w", err)
+	if _, err := config.EnsureCursorACP(); err != nil {
+		return NewConfigError("cannot add the cursor-acp provider", m.configPath, err)
 	}
 
-	// Ensure provider section exists
-	providers, ok := config["provider"].(map[string]interface{})
-	if !ok {
-		providers = make(map[string]interface{})
-		config["provider"] = providers
-	}
-
-	existingCursorAcp, ok := providers["cursor-acp"].(map[string]interface{})
-	if !ok {
-		if providers["cursor-acp"] != nil {
-			return fmt.Errorf("cursor-acp provider has invalid type (expected object, got %T)", providers["cursor-acp"])
+	// Keep existing npm entries in step with the version being installed.
+	spec := preferredPluginSpec(m)
+	for i, p := range config.Plugin {
+		if p.Kind() == opencodeconfig.PluginNPM {
+			config.Plugin[i] = opencodeconfig.Plugin(spec)
 		}
-		existingCursorAcp = make(map[string]interface{})
-	}
-	if _, hasName := existingCursorAcp["name"]; !hasName {
-		existingCursorAcp["name"] = "Cursor Agent (ACP stdin)"
-	}
-	const defaultBaseURL = "http://127.0.0.1:32124/v1"
-	opts, _ := existingCursorAcp["options"].(map[string]interface{})
-	if opts == nil {
-		opts = make(map[string]interface{})
-		existingCursorAcp["options"] = opts
 	}
-	if _, hasBaseURL := opts["baseURL"]; !hasBaseURL {
-		opts["baseURL"] = defaultBaseURL
+	if !config.HasPlugin(opencodeconfig.PluginEntry.IsCursorACP) {
+		config.AddPlugin(spec)
 	}
-	providers["cursor-acp"] = existingCursorAcp
 
-	plugins, ok := config["plugin"].([]interface{})
-	if !ok {
-		plugins = []interface{}{}
-	}
-
-	hasPlugin := false
-	for _, p := range plugins {
-		s, ok := p.(string)
-		if ok && (s == "cursor-acp" || strings.HasPrefix(s, npmPackage)) {
-			hasPlugin = true
-			break
-		}
-	}
-	if !hasPlugin {
-		plugins = append(plugins, npmPackage+"@latest")
-		config["plugin"] = plugins
-	}
-
-	output, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to serialize config: %w", err)
-	}
-	if err := os.WriteFile(m.configPath, output, 0644); err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
+	if err := saveTrackedConfig(m.configPath, before, config); err != nil {
+		return err
 	}
-
-	return nil
+	return recordInstallSource(manifestSource{Kind: "plugin-array", Location: spec}, "")
 }
 
 func fetchAndAddModels(m *model) error {
-	data, err := os.ReadFile(m.configPath)
+	config, err := opencodeconfig.Load(m.configPath)
 	if err != nil {
 		return fmt.Errorf("failed to read config: %w", err)
 	}
 
-	var config map[string]interface{}
-	if err := json.Unmarshal(data, &config); err != nil {
-		return fmt.Errorf("failed to parse config: %w", err)
-	}
-
-	providers, ok := config["provider"].(map[string]interface{})
-	if !ok {
-		providers = make(map[string]interface{})
-		config["provider"] = providers
-	}
-
-	existingCursorAcp, ok := providers["cursor-acp"].(map[string]interface{})
-	if !ok {
-		if providers["cursor-acp"] != nil {
-			return fmt.Errorf("cursor-acp provider has invalid type (expected object, got %T)", providers["cursor-acp"])
-		}
-		existingCursorAcp = make(map[string]interface{})
-	}
+	before := config.Clone()
 
 	models, err := fetchCursorModels()
 	if err != nil {
 		return fmt.Errorf("failed to fetch models from cursor-agent: %w", err)
 	}
-	existingCursorAcp["models"] = models
-	providers["cursor-acp"] = existingCursorAcp
-
-	output, err := json.MarshalIndent(config, "", "  ")
+	provider, err := config.EnsureProvider(opencodeconfig.ProviderID)
 	if err != nil {
-		return fmt.Errorf("failed to serialize config: %w", err)
+		return NewConfigError("cannot add models", m.configPath, err)
 	}
-	if err := os.WriteFile(m.configPath, output, 0644); err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
+	provider.SetModels(models)
+
+	if err := saveTrackedConfig(m.configPath, before, config); err != nil {
+		return err
 	}
 
+	m.prompt = lintModelRefsPrompt(config)
+
 	return nil
 }
 
 func validateConfig(m *model) error {
-	if err := validateJSON(m.configPath); err != nil {
-		return NewValidationError("config validation failed", m.configPath, err)
+	warnings, err := opencodeconfig.ValidateFile(m.configPath)
+	var invalid *opencodeconfig.ValidationError
+	if errors.As(err, &invalid) {
+		return NewValidationError("config does not match the OpenCode schema", formatConfigIssues(m.configPath, invalid.Issues), nil)
 	}
-
-	data, err := os.ReadFile(m.configPath)
 	if err != nil {
-		return NewConfigError("failed to read config for validation", m.configPath, err)
+		return NewValidationError("config validation failed", m.configPath, err)
+	}
+	if m.logFile != nil {
+		for _, w := range warnings {
+			m.logFile.WriteString("Config warning: " + w.String() + "\n")
+		}
 	}
 
-	var config map[string]interface{}
-	if err := json.Unmarshal(data, &config); err != nil {
+	config, err := opencodeconfig.Load(m.configPath)
+	if err != nil {
 		return NewConfigError("failed to parse config JSON", m.configPath, err)
 	}
 
-	providers, ok := config["provider"].(map[string]interface{})
-	if !ok {
+	if config.Provider == nil {
 		return NewValidationError("provider section missing from config", m.configPath, nil)
 	}
 
-	if _, exists := providers["cursor-acp"]; !exists {
+	if config.CursorACP() == nil {
 		return NewValidationError("cursor-acp provider not found in config", m.configPath, nil)
 	}
 
+	// Agents and modes pointing at models that vanished from the sync fail
+	// silently in OpenCode, so surface them here.
+	m.prompt = lintModelRefsPrompt(config)
+
 	return nil
 }
 
@@ -665,61 +565,28 @@ func cleanupBackups(m *model) {
 	m.backupFiles = make(map[string][]byte)
 }
 
-// backupConfigToDisk writes a timestamped backup alongside the given file.
-// Failures are intentionally non-fatal to avoid blocking installation.
-func backupConfigToDisk(path string) error {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return err
-	}
-
-	ts := time.Now().Format("20060102-150405")
-	backupPath := fmt.Sprintf("%s.bak.%s", path, ts)
-	return os.WriteFile(backupPath, data, 0644)
-}
-
 // Uninstall functions
-func (m model) startUninstallation() (tea.Model, tea.Cmd) {
-	m.step = stepUninstalling
-	m.isUninstall = true
-
-	m.tasks = []installTask{
-		{name: "Remove plugin symlink", description: "Removing cursor-acp.js from plugin directory", execute: removeSymlink, status: statusPending},
-		{name: "Remove ACP SDK", description: "Removing @agentclientprotocol/sdk from opencode", execute: removeAcpSdk, status: statusPending},
-		{name: "Remove provider config", description: "Removing cursor-acp from opencode.json", execute: removeProviderConfig, status: statusPending},
-		{name: "Remove old plugin", description: "Removing cursor-acp-auth if present", execute: removeOldPlugin, status: statusPending},
-		{name: "Validate config", description: "Checking JSON syntax", execute: validateConfigAfterUninstall, status: statusPending},
-	}
-
-	m.currentTaskIndex = 0
-	m.tasks[0].status = statusRunning
-	return m, tea.Batch(m.spinner.Tick, executeTaskCmd(0, &m))
-}
 
+// removeSymlink removes cursor-acp.js whether it was installed as an
+// absolute symlink, a relative one or a copy.
 func removeSymlink(m *model) error {
-	// Remove symlink from plugin directory
-	symlinkPath := filepath.Join(m.pluginDir, "cursor-acp.js")
+	symlinkPath := pluginFilePath(m.pluginDir)
 
-	// Check if symlink exists
-	if _, err := os.Lstat(symlinkPath); os.IsNotExist(err) {
-		// Symlink doesn't exist, that's fine - already uninstalled
+	mode := detectLinkMode(symlinkPath)
+	if mode == "" {
+		// Nothing there, that's fine - already uninstalled
 		return nil
 	}
+	if m.logFile != nil {
+		m.logFile.WriteString(fmt.Sprintf("Removing %s (%s)\n", symlinkPath, mode))
+	}
 
-	// Remove symlink
 	if err := os.Remove(symlinkPath); err != nil {
-		return fmt.Errorf("failed to remove symlink: %w", err)
+		return fmt.Errorf("failed to remove %s: %w", symlinkPath, err)
 	}
 
 	// Also remove old node_modules symlink if it exists (migration from older installer)
-	configDir, _ := getConfigDir()
-	oldNodeModulesPath := filepath.Join(configDir, "opencode", "node_modules", "cursor-acp")
-	if _, err := os.Lstat(oldNodeModulesPath); err == nil {
-		os.Remove(oldNodeModulesPath)
-	}
+	_ = removeOldNodeModulesLink(m)
 
 	return nil
 }
@@ -774,13 +641,13 @@ func removeAcpSdk(m *model) error {
 }
 
 func removeProviderConfig(m *model) error {
-	_ = backupConfigToDisk(m.configPath)
+	_ = backupConfigToDisk(m.configPath, m.currentTaskName())
 	if err := createBackup(m, m.configPath); err != nil {
 		return fmt.Errorf("failed to backup config: %w", err)
 	}
 
 	// Read existing config
-	data, err := os.ReadFile(m.configPath)
+	config, err := opencodeconfig.Load(m.configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil
@@ -788,42 +655,11 @@ func removeProviderConfig(m *model) error {
 		return fmt.Errorf("failed to read config: %w", err)
 	}
 
-	var config map[string]interface{}
-	if err := json.Unmarshal(data, &config); err != nil {
-		return fmt.Errorf("failed to parse config: %w", err)
-	}
-
-	// Remove cursor-acp provider
-	if providers, ok := config["provider"].(map[string]interface{}); ok {
-		if _, exists := providers["cursor-acp"]; exists {
-			delete(providers, "cursor-acp")
-		}
-	}
-
-	// Remove cursor-acp from plugin array
-	if plugins, ok := config["plugin"].([]interface{}); ok {
-		filtered := make([]interface{}, 0)
-		for _, p := range plugins {
-			s, ok := p.(string)
-			if ok && (s == "cursor-acp" || strings.HasPrefix(s, npmPackage)) {
-				continue
-			}
-			filtered = append(filtered, p)
-		}
-		config["plugin"] = filtered
-	}
-
-	// Write config back
-	output, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to serialize config: %w", err)
-	}
-
-	if err := os.WriteFile(m.configPath, output, 0644); err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
-	}
+	// Remove cursor-acp provider and plugin entries
+	config.RemoveProvider(opencodeconfig.ProviderID)
+	config.RemovePlugins(opencodeconfig.PluginEntry.IsCursorACP)
 
-	return nil
+	return config.Save(m.configPath)
 }
 
 func validateConfigAfterUninstall(m *model) error {
@@ -832,29 +668,26 @@ func validateConfigAfterUninstall(m *model) error {
 	}
 
 	// Verify cursor-acp provider is removed
-	data, _ := os.ReadFile(m.configPath)
-	var config map[string]interface{}
-	json.Unmarshal(data, &config)
-
-	if providers, ok := config["provider"].(map[string]interface{}); ok {
-		if _, exists := providers["cursor-acp"]; exists {
-			return fmt.Errorf("cursor-acp provider still exists in config")
-		}
+	config, err := opencodeconfig.Load(m.configPath)
+	if err != nil {
+		return fmt.Errorf("config validation failed: %w", err)
+	}
+	if config.CursorACP() != nil {
+		return fmt.Errorf("cursor-acp provider still exists in config")
 	}
 
 	return nil
 }
 
 func removeOldPlugin(m *model) error {
-	configDir, _ := getConfigDir()
-	configPath := filepath.Join(configDir, "opencode", "opencode.json")
+	configPath := m.configPath
 
-	_ = backupConfigToDisk(configPath)
+	_ = backupConfigToDisk(configPath, m.currentTaskName())
 	if err := createBackup(m, configPath); err != nil {
 		return fmt.Errorf("failed to backup config: %w", err)
 	}
 
-	data, err := os.ReadFile(configPath)
+	config, err := opencodeconfig.Load(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil
@@ -862,35 +695,16 @@ func removeOldPlugin(m *model) error {
 		return fmt.Errorf("failed to read config: %w", err)
 	}
 
-	var config map[string]interface{}
-	if err := json.Unmarshal(data, &config); err != nil {
-		return fmt.Errorf("failed to parse config: %w", err)
-	}
+	config.RemovePlugins(opencodeconfig.PluginEntry.IsLegacyAuth)
 
-	if plugins, ok := config["plugin"].([]interface{}); ok {
-		var newPlugins []interface{}
-		for _, p := range plugins {
-			pluginStr, ok := p.(string)
-			if !ok {
-				continue
-			}
-			if !strings.HasPrefix(pluginStr, "cursor-acp-auth") {
-				newPlugins = append(newPlugins, pluginStr)
-			}
-		}
-		config["plugin"] = newPlugins
+	if err := config.Save(configPath); err != nil {
+		return err
 	}
 
-	output, err := json.MarshalIndent(config, "", "  ")
+	cacheDir, err := getOpenCodeModulesDir()
 	if err != nil {
-		return fmt.Errorf("failed to serialize config: %w", err)
-	}
-
-	if err := os.WriteFile(configPath, output, 0644); err != nil {
-		return fmt.Errorf("failed to write config: %w", err)
+		return nil
 	}
-
-	cacheDir := filepath.Join(os.Getenv("HOME"), ".cache", "opencode", "node_modules")
 	oldPluginPath := filepath.Join(cacheDir, "cursor-acp-auth")
 	if _, err := os.Stat(oldPluginPath); err == nil {
 		if err := os.RemoveAll(oldPluginPath); err != nil {
@@ -933,8 +747,36 @@ func (m model) handleTaskComplete(msg taskCompleteMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
-	// Move to next task
+	if msg.success {
+		skipTasks(&m, msg.skip)
+	}
+
+	if msg.success && msg.prompt != nil {
+		m.prompt = msg.prompt
+		m.step = stepPrompt
+		return m, nil
+	}
+
+	return m.advanceTask()
+}
+
+// skipTasks marks the named pending tasks as skipped.
+func skipTasks(m *model, names []string) {
+	for _, name := range names {
+		for i := range m.tasks {
+			if m.tasks[i].name == name && m.tasks[i].status == statusPending {
+				m.tasks[i].status = statusSkipped
+			}
+		}
+	}
+}
+
+// advanceTask starts the task after the current one, or finishes the run.
+func (m model) advanceTask() (tea.Model, tea.Cmd) {
 	m.currentTaskIndex++
+	for m.currentTaskIndex < len(m.tasks) && m.tasks[m.currentTaskIndex].status == statusSkipped {
+		m.currentTaskIndex++
+	}
 	if m.currentTaskIndex >= len(m.tasks) {
 		cleanupBackups(&m)
 		m.step = stepComplete
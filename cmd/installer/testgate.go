@@ -0,0 +1,146 @@
+// cmd/installer/testgate.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unitTestScript is the package.json script run by the test gate; CI runs
+// the same one.
+const unitTestScript = "test:ci:unit"
+
+// maxFailingShown caps the failing test names listed in the prompt.
+const maxFailingShown = 12
+
+// testSummary is the result of a `bun test` run.
+type testSummary struct {
+	pass, fail, skip int
+	failing          []string
+}
+
+var (
+	bunCountRegex    = regexp.MustCompile(`^\s*(\d+) (pass|fail|skip)\s*$`)
+	bunFailRegex     = regexp.MustCompile(`^\s*(?:\(fail\)|✗)\s+(.+?)(?:\s+\[[\d.]+m?s\])?\s*$`)
+	bunDurationRegex = regexp.MustCompile(`\s+\[[\d.]+m?s\]$`)
+)
+
+// parseBunTestOutput extracts the pass/fail/skip counts and failing test
+// names from `bun test` output. Bun repeats failures in its closing summary,
+// so names are de-duplicated.
+func parseBunTestOutput(output string) testSummary {
+	var s testSummary
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(stripANSI(output), "\n") {
+		if m := bunCountRegex.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			switch m[2] {
+			case "pass":
+				s.pass = n
+			case "fail":
+				s.fail = n
+			case "skip":
+				s.skip = n
+			}
+			continue
+		}
+		if m := bunFailRegex.FindStringSubmatch(line); m != nil {
+			name := bunDurationRegex.ReplaceAllString(m[1], "")
+			if !seen[name] {
+				seen[name] = true
+				s.failing = append(s.failing, name)
+			}
+		}
+	}
+	return s
+}
+
+func (s testSummary) String() string {
+	out := fmt.Sprintf("%d passed, %d failed", s.pass, s.fail)
+	if s.skip > 0 {
+		out += fmt.Sprintf(", %d skipped", s.skip)
+	}
+	return out
+}
+
+// hasPackageScript reports whether dir/package.json defines script.
+func hasPackageScript(dir, script string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return false
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return false
+	}
+	_, ok := pkg.Scripts[script]
+	return ok
+}
+
+// runPluginTests is the optional "Run tests" task after Build plugin. It
+// runs the unit suite in the checkout; when anything fails the user decides
+// whether the build still gets linked into OpenCode.
+func runPluginTests(m *model) error {
+	if !hasPackageScript(m.projectDir, unitTestScript) {
+		if m.logFile != nil {
+			m.logFile.WriteString(fmt.Sprintf("%s has no %q script, skipping tests\n", m.projectDir, unitTestScript))
+		}
+		return nil
+	}
+
+	cmd := exec.CommandContext(m.ctx, "bun", "run", unitTestScript)
+	cmd.Dir = m.projectDir
+	output, runErr := cmd.CombinedOutput()
+	if m.logFile != nil {
+		m.logFile.WriteString(fmt.Sprintf("[%s] Running: %s\n", time.Now().Format("15:04:05"), cmd.String()))
+		m.logFile.Write(output)
+		m.logFile.WriteString("\n")
+		m.logFile.Sync()
+	}
+
+	summary := parseBunTestOutput(string(output))
+	if runErr == nil && summary.fail == 0 {
+		m.prompt = &taskPrompt{
+			title:   "Unit tests passed",
+			body:    []string{summary.String()},
+			options: []promptOption{{key: "enter", label: "Continue"}},
+		}
+		return nil
+	}
+
+	title := fmt.Sprintf("%d unit tests failed", summary.fail)
+	body := []string{summary.String()}
+	if summary.fail == 0 {
+		// Non-zero exit without failures: the suite didn't run to the end.
+		title = "Unit tests did not complete"
+		body = []string{fmt.Sprintf("bun run %s: %v", unitTestScript, runErr)}
+	}
+	for i, name := range summary.failing {
+		if i == maxFailingShown {
+			body = append(body, fmt.Sprintf("… and %d more, see the install log", len(summary.failing)-i))
+			break
+		}
+		body = append(body, "✗ "+name)
+	}
+
+	m.prompt = &taskPrompt{
+		title: title,
+		body:  body,
+		options: []promptOption{
+			{key: "l", label: "Link anyway"},
+			{key: "c", label: "Don't link", apply: func(m *model, _ string) error {
+				return fmt.Errorf("unit tests failed; %s was built but not linked", m.projectDir)
+			}},
+		},
+	}
+	return nil
+}
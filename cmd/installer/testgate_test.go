@@ -0,0 +1,51 @@
+// cmd/installer/testgate_test.go
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBunTestOutput(t *testing.T) {
+	output := `bun test v1.2.4
+
+tests/unit/auth.test.ts:
+(pass) auth > reads token [0.51ms]
+(fail) auth > refreshes expired token [2.10ms]
+  error: expect(received).toBe(expected)
+
+tests/unit/plugin.test.ts:
+(pass) plugin > registers provider
+(fail) plugin > loads config
+
+2 tests failed:
+(fail) auth > refreshes expired token [2.10ms]
+(fail) plugin > loads config
+
+ 2 pass
+ 1 skip
+ 2 fail
+ 6 expect() calls
+Ran 5 tests across 2 files. [120.00ms]
+`
+	got := parseBunTestOutput(output)
+	want := testSummary{
+		pass:    2,
+		fail:    2,
+		skip:    1,
+		failing: []string{"auth > refreshes expired token", "plugin > loads config"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseBunTestOutput() = %+v, want %+v", got, want)
+	}
+	if s := got.String(); s != "2 passed, 2 failed, 1 skipped" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestParseBunTestOutputAllPass(t *testing.T) {
+	got := parseBunTestOutput("\x1b[32m 42 pass\x1b[0m\n 0 fail\nRan 42 tests across 9 files.\n")
+	if got.pass != 42 || got.fail != 0 || len(got.failing) != 0 {
+		t.Fatalf("parseBunTestOutput() = %+v", got)
+	}
+}
@@ -0,0 +1,241 @@
+// cmd/installer/toggle.go
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+// disabledStash is what disable took out of opencode.json, kept in the state
+// dir until enable puts it back. The plugin reads a missing plugin entry as
+// disabled (src/plugin-toggle.ts), so the plugin file can stay.
+type disabledStash struct {
+	DisabledAt time.Time       `json:"disabledAt"`
+	ConfigPath string          `json:"configPath"`
+	Provider   json.RawMessage `json:"provider,omitempty"`
+	Plugins    []stashedPlugin `json:"plugins,omitempty"`
+}
+
+// stashedPlugin is a plugin entry and where it sat in the array.
+type stashedPlugin struct {
+	Index int                        `json:"index"`
+	Entry opencodeconfig.PluginEntry `json:"entry"`
+}
+
+func stashPath() (string, error) {
+	stateDir, err := getStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "disabled.json"), nil
+}
+
+// loadDisabledStash returns the stash, or nil when the plugin isn't disabled.
+func loadDisabledStash() (*disabledStash, error) {
+	path, err := stashPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stash disabledStash
+	if err := json.Unmarshal(data, &stash); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &stash, nil
+}
+
+// isPluginDisabled reports whether disable has stashed the plugin.
+func isPluginDisabled() bool {
+	stash, err := loadDisabledStash()
+	return err == nil && stash != nil
+}
+
+// disablePlugin moves provider.cursor-acp and the cursor-acp plugin entries
+// from configPath into the stash.
+func disablePlugin(configPath string) (*disabledStash, error) {
+	if stash, err := loadDisabledStash(); err != nil {
+		return nil, err
+	} else if stash != nil {
+		return nil, fmt.Errorf("cursor-acp is already disabled (since %s)", stash.DisabledAt.Local().Format("2006-01-02 15:04"))
+	}
+
+	config, err := opencodeconfig.Load(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	stash := &disabledStash{
+		DisabledAt: time.Now().UTC(),
+		ConfigPath: configPath,
+		Provider:   configValue(config, configKeyProvider),
+	}
+	for i, entry := range config.Plugin {
+		if entry.IsCursorACP() {
+			stash.Plugins = append(stash.Plugins, stashedPlugin{Index: i, Entry: entry})
+		}
+	}
+	if stash.Provider == nil && len(stash.Plugins) == 0 {
+		return nil, fmt.Errorf("cursor-acp is not configured in %s", configPath)
+	}
+
+	// Write the stash first: losing it would lose the user's customizations.
+	stateDir, err := ensureStateDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(stash, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(stateDir, "disabled.json"), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write stash: %w", err)
+	}
+
+	_ = backupConfigToDisk(configPath, "disable")
+	config.RemoveProvider(opencodeconfig.ProviderID)
+	config.RemovePlugins(opencodeconfig.PluginEntry.IsCursorACP)
+	if err := config.Save(configPath); err != nil {
+		return nil, err
+	}
+	return stash, nil
+}
+
+// enablePlugin puts the stashed provider block and plugin entries back as
+// they were and removes the stash.
+func enablePlugin() (*disabledStash, error) {
+	stash, err := loadDisabledStash()
+	if err != nil {
+		return nil, err
+	}
+	if stash == nil {
+		return nil, fmt.Errorf("cursor-acp is not disabled")
+	}
+
+	config, err := opencodeconfig.LoadOrEmpty(stash.ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if stash.Provider != nil {
+		if config.CursorACP() != nil {
+			return nil, fmt.Errorf("%s already has a %s block; remove it or edit the stash before enabling", stash.ConfigPath, configKeyProvider)
+		}
+		if err := setConfigValue(config, configKeyProvider, stash.Provider); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", configKeyProvider, err)
+		}
+	}
+	for _, p := range stash.Plugins {
+		raw, _ := json.Marshal(p.Entry)
+		if config.HasPlugin(func(e opencodeconfig.PluginEntry) bool {
+			other, _ := json.Marshal(e)
+			return string(other) == string(raw)
+		}) {
+			continue
+		}
+		index := p.Index
+		if index > len(config.Plugin) {
+			index = len(config.Plugin)
+		}
+		config.Plugin = append(config.Plugin[:index], append([]opencodeconfig.PluginEntry{p.Entry}, config.Plugin[index:]...)...)
+	}
+
+	_ = backupConfigToDisk(stash.ConfigPath, "enable")
+	if err := config.Save(stash.ConfigPath); err != nil {
+		return nil, err
+	}
+
+	path, err := stashPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Remove(path); err != nil {
+		return nil, fmt.Errorf("failed to remove stash: %w", err)
+	}
+	return stash, nil
+}
+
+// describeStash summarizes what a stash holds.
+func describeStash(stash *disabledStash) string {
+	var parts []string
+	if stash.Provider != nil {
+		parts = append(parts, configKeyProvider)
+	}
+	for _, p := range stash.Plugins {
+		if p.Entry.IsString() {
+			parts = append(parts, fmt.Sprintf("plugin %q", p.Entry.Spec))
+		} else {
+			parts = append(parts, "a plugin entry")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// toggleFromWelcome is the welcome-screen action: disable when enabled,
+// enable when disabled.
+func (m *model) toggleFromWelcome() {
+	if m.disabled {
+		stash, err := enablePlugin()
+		if err != nil {
+			m.notice = "Enable failed: " + err.Error()
+			return
+		}
+		m.notice = "Enabled: restored " + describeStash(stash)
+		m.existingSetup = true
+		m.disabled = false
+		return
+	}
+
+	stash, err := disablePlugin(m.configPath)
+	if err != nil {
+		m.notice = "Disable failed: " + err.Error()
+		return
+	}
+	m.notice = "Disabled: stashed " + describeStash(stash) + ". Restart OpenCode to apply."
+	m.disabled = true
+}
+
+func cmdDisable(args []string) error {
+	fs, configPath := newFlagSet("disable")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	stash, err := disablePlugin(*configPath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Disabled cursor-acp: stashed %s\n", describeStash(stash))
+
+	if config, err := opencodeconfig.Load(*configPath); err == nil {
+		for _, issue := range lintModelRefs(config) {
+			fmt.Fprintf(os.Stderr, "Warning: %s still refers to cursor-acp\n", issue)
+		}
+	}
+	return nil
+}
+
+func cmdEnable(args []string) error {
+	// The stash records which config it came from, so no --config here.
+	fs := flag.NewFlagSet("enable", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	stash, err := enablePlugin()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Enabled cursor-acp: restored %s in %s\n", describeStash(stash), stash.ConfigPath)
+	return nil
+}
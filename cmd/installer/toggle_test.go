@@ -0,0 +1,73 @@
+// cmd/installer/toggle_test.go
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDisableEnableRoundTrip(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "opencode.json")
+	original := `{
+  "plugin": [
+    "other-plugin",
+    "cursor-acp",
+    "another-plugin"
+  ],
+  "provider": {
+    "cursor-acp": {
+      "name": "My Cursor",
+      "npm": "@ai-sdk/openai-compatible",
+      "options": {
+        "baseURL": "http://127.0.0.1:4000/v1",
+        "timeout": 60000
+      },
+      "models": {
+        "auto": {
+          "name": "Auto"
+        }
+      }
+    }
+  }
+}`
+	writeTestConfig(t, path, original)
+
+	if _, err := disablePlugin(path); err != nil {
+		t.Fatal(err)
+	}
+	config := loadTestConfig(t, path)
+	if config.CursorACP() != nil || len(config.Plugin) != 2 {
+		t.Fatalf("after disable: provider %v, plugins %v", config.CursorACP(), config.Plugin)
+	}
+	if !isPluginDisabled() {
+		t.Error("isPluginDisabled() = false after disable")
+	}
+	if _, err := disablePlugin(path); err == nil {
+		t.Error("second disable succeeded")
+	}
+
+	if _, err := enablePlugin(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(original), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("enable did not restore the config exactly:\n%s", data)
+	}
+	if isPluginDisabled() {
+		t.Error("stash left behind after enable")
+	}
+}
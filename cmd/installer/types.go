@@ -17,8 +17,12 @@ const (
 	stepWelcome installStep = iota
 	stepSelectMode
 	stepInstalling
+	stepPrompt
 	stepUninstalling
 	stepComplete
+	stepDev
+	stepConfirmUninstall
+	stepRepair
 )
 
 // Task status
@@ -42,6 +46,26 @@ type installTask struct {
 	errorDetails *errorInfo
 }
 
+// taskPrompt pauses the pipeline after a task so the user can choose how to
+// continue. Tasks set model.prompt; the choice is applied before the next task.
+// An apply func may set model.prompt again to chain a follow-up prompt.
+type taskPrompt struct {
+	title   string
+	body    []string
+	options []promptOption
+
+	// Optional list the user picks from with the arrow keys; the highlighted
+	// entry is passed to the option's apply func.
+	choices []string
+	cursor  int
+}
+
+type promptOption struct {
+	key   string
+	label string
+	apply func(m *model, choice string) error // nil = just continue
+}
+
 type errorInfo struct {
 	message string
 	command string
@@ -81,13 +105,59 @@ type model struct {
 	checksComplete bool
 
 	// Installation paths
-	projectDir    string
-	pluginEntry   string
-	pluginDir     string
-	configPath    string
-	existingSetup bool
-	isUninstall   bool
-	npmTag        string
+	projectDir     string
+	pluginEntry    string
+	pluginDir      string
+	configPath     string
+	existingSetup  bool
+	isUninstall    bool
+	isUpgrade      bool
+	npmTag         string
+	probeModels    bool
+	chooseDefaults bool
+	runTests       bool
+	linkMode       string // linkModeSymlink, linkModeRelative or linkModeCopy
+
+	// Pre-install snapshot of the config dir, optionally with node_modules
+	snapshot            bool
+	snapshotNodeModules bool
+
+	// Explicit plugin source from --from-tarball/--from-dir/--from-git
+	installSource *installSource
+
+	// Ref and commit built by Build from Source, shown on completion
+	sourceRef    string
+	sourceCommit string
+
+	// Version of the globally installed npm package, "" if none; offered
+	// for pinning on the mode screen.
+	installedVersion string
+
+	// Defaults chosen for opencode.json "model" / "small_model"
+	defaultModel string
+	smallModel   string
+
+	// Prompt raised by the last task, shown in stepPrompt
+	prompt *taskPrompt
+
+	// Later tasks the last task found unnecessary, by name
+	skipTasks []string
+
+	// Dev-link mode session, shown in stepDev
+	dev *devSession
+
+	// Whether disable has stashed the plugin, and the last toggle's result
+	disabled bool
+	notice   string
+
+	// Uninstall level and preview, shown in stepConfirmUninstall
+	uninstallPlan *uninstallPlan
+
+	// Component health and pending migrations before a repair, shown in
+	// stepRepair
+	isRepair bool
+	health   []componentHealth
+	legacy   []pendingMigration
 
 	// Context for cancellation
 	ctx    context.Context
@@ -102,6 +172,8 @@ type taskCompleteMsg struct {
 	index   int
 	success bool
 	err     string
+	prompt  *taskPrompt
+	skip    []string
 }
 
 type checksCompleteMsg struct {
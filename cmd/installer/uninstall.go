@@ -0,0 +1,397 @@
+// cmd/installer/uninstall.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+// uninstallLevel is how much uninstall removes; each level includes the
+// ones before it.
+type uninstallLevel int
+
+const (
+	// uninstallConfig removes the provider and plugin entries from
+	// opencode.json.
+	uninstallConfig uninstallLevel = iota
+	// uninstallPlugin also removes the plugin file and the packages and
+	// clones install added.
+	uninstallPlugin
+	// uninstallPurge also removes everything else the installer or OpenCode
+	// keeps for the plugin: the npm global package, OpenCode's cached copy,
+	// backups, logs, scheduled syncs and the state dir.
+	uninstallPurge
+)
+
+var uninstallLevelNames = []string{"config", "plugin", "purge"}
+
+func (l uninstallLevel) String() string {
+	return uninstallLevelNames[l]
+}
+
+func (l uninstallLevel) label() string {
+	switch l {
+	case uninstallConfig:
+		return "Config only"
+	case uninstallPlugin:
+		return "Plugin + config"
+	}
+	return "Full purge"
+}
+
+func parseUninstallLevel(s string) (uninstallLevel, error) {
+	for i, name := range uninstallLevelNames {
+		if s == name {
+			return uninstallLevel(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid uninstall level %q: expected %s", s, strings.Join(uninstallLevelNames, ", "))
+}
+
+// uninstallPlan is what uninstall will do at a level: the tasks, and a
+// line per item they remove for the preview.
+type uninstallPlan struct {
+	level   uninstallLevel
+	tasks   []installTask
+	preview []string
+}
+
+// planUninstall works out what uninstall removes at level. With an install
+// manifest the recorded changes are reverted; older installs fall back to
+// removing the known locations.
+func planUninstall(m *model, level uninstallLevel) *uninstallPlan {
+	plan := &uninstallPlan{level: level}
+	add := func(task installTask, preview ...string) {
+		task.status = statusPending
+		plan.tasks = append(plan.tasks, task)
+		plan.preview = append(plan.preview, preview...)
+	}
+
+	mf, err := loadInstallManifest()
+	if err == nil && len(mf.Changes) > 0 {
+		selected := func(c manifestChange) bool {
+			return level > uninstallConfig || c.Kind == changeConfig
+		}
+		var preview []string
+		for _, c := range mf.Changes {
+			if selected(c) {
+				preview = append(preview, describeChange(c))
+			}
+		}
+		add(installTask{name: "Revert install", description: "Undoing the changes recorded in the install manifest", execute: revertInstallTask(selected)}, preview...)
+		add(installTask{name: "Validate config", description: "Checking JSON syntax", execute: validateJSONAfterRevert})
+	} else {
+		if level >= uninstallPlugin {
+			if mode := detectLinkMode(pluginFilePath(m.pluginDir)); mode != "" {
+				add(installTask{name: "Remove plugin symlink", description: "Removing cursor-acp.js (symlink or copy) from plugin directory", execute: removeSymlink},
+					fmt.Sprintf("%s (%s)", pluginFilePath(m.pluginDir), mode))
+			}
+			configDir, _ := getConfigDir()
+			acpPath := filepath.Join(configDir, "opencode", "node_modules", "@agentclientprotocol", "sdk")
+			if pathExists(acpPath) {
+				add(installTask{name: "Remove ACP SDK", description: "Removing @agentclientprotocol/sdk from opencode", execute: removeAcpSdk}, acpPath)
+			}
+		}
+		add(installTask{name: "Remove provider config", description: "Removing cursor-acp from opencode.json", execute: removeProviderConfig},
+			fmt.Sprintf("%s: %s and cursor-acp plugin entries", m.configPath, configKeyProvider))
+		add(installTask{name: "Remove old plugin", description: "Removing cursor-acp-auth if present", execute: removeOldPlugin},
+			fmt.Sprintf("%s: %s plugin entries, if any", m.configPath, opencodeconfig.LegacyAuthPlugin))
+		add(installTask{name: "Validate config", description: "Checking JSON syntax", execute: validateConfigAfterUninstall})
+	}
+
+	if stash, err := loadDisabledStash(); err == nil && stash != nil {
+		add(installTask{name: "Discard disabled stash", description: "Removing what disable stashed", execute: removeDisabledStash},
+			"disabled stash: "+describeStash(stash))
+	}
+
+	if level < uninstallPurge {
+		return plan
+	}
+
+	if version := installedNpmVersion(); version != "" {
+		add(installTask{name: "Remove npm package", description: "npm uninstall -g " + npmPackage, execute: removeNpmGlobalPackage},
+			fmt.Sprintf("npm global package %s %s", npmPackage, version))
+	}
+	if modulesDir, err := getOpenCodeModulesDir(); err == nil {
+		if path := filepath.Join(modulesDir, filepath.FromSlash(npmPackage)); pathExists(path) {
+			add(installTask{name: "Clear OpenCode cache", description: "Removing OpenCode's cached copy of the plugin", execute: clearOpenCodePluginCache}, path)
+		}
+	}
+	if managedDir, err := getManagedDir(); err == nil && pathExists(managedDir) {
+		add(installTask{name: "Remove clones", description: "Removing installer-managed clones and releases", execute: removeManagedDir}, managedDir)
+	}
+	if backups, legacy := configBackupCount(m.configPath); backups+legacy > 0 {
+		var items []string
+		if dir, err := getBackupsDir(); err == nil && backups > 0 {
+			items = append(items, fmt.Sprintf("%d config backup(s) in %s", backups, dir))
+		}
+		if legacy > 0 {
+			items = append(items, fmt.Sprintf("%d old config backup(s) next to %s", legacy, m.configPath))
+		}
+		add(installTask{name: "Remove backups", description: "Removing opencode.json backups", execute: removeConfigBackups}, items...)
+	}
+	if logs := installerLogs(m); len(logs) > 0 {
+		add(installTask{name: "Remove logs", description: "Removing old installer logs", execute: removeInstallerLogs},
+			fmt.Sprintf("%d installer log(s) in %s", len(logs), os.TempDir()))
+	}
+	if entries := findScheduledSync(); len(entries) > 0 {
+		add(installTask{name: "Remove scheduled sync", description: "Removing the background sync-models job", execute: removeScheduledSyncTask}, entries...)
+	}
+	if stateDir, err := getStateDir(); err == nil && pathExists(stateDir) {
+		add(installTask{name: "Remove state", description: "Removing the installer state directory", execute: removeStateDir}, stateDir)
+	}
+	return plan
+}
+
+// describeChange is the preview line for reverting c.
+func describeChange(c manifestChange) string {
+	switch c.Kind {
+	case changeConfig:
+		if c.Key == configKeyPlugin {
+			if prior := priorString(c.Prior); prior != "" {
+				return fmt.Sprintf("%s: plugin %q (back to %q)", c.Path, c.Value, prior)
+			}
+			return fmt.Sprintf("%s: plugin %q", c.Path, c.Value)
+		}
+		if c.Existed {
+			return fmt.Sprintf("%s: %s (back to its previous value)", c.Path, c.Key)
+		}
+		return fmt.Sprintf("%s: %s", c.Path, c.Key)
+	case changeFile:
+		if prior := priorString(c.Prior); prior != "" {
+			return fmt.Sprintf("%s (back to → %s)", c.Path, prior)
+		}
+		if c.Existed {
+			return fmt.Sprintf("%s (kept: existed before)", c.Path)
+		}
+		return c.Path
+	case changePackage:
+		if c.Existed {
+			return fmt.Sprintf("%s (kept: installed before)", c.Key)
+		}
+		return fmt.Sprintf("%s (%s)", c.Key, c.Via)
+	case changeDir:
+		if c.Existed {
+			return fmt.Sprintf("%s (kept: existed before)", c.Path)
+		}
+	}
+	return c.Path
+}
+
+// startUninstallation runs the confirmed plan.
+func (m model) startUninstallation() (tea.Model, tea.Cmd) {
+	if m.uninstallPlan == nil {
+		m.uninstallPlan = planUninstall(&m, uninstallPlugin)
+	}
+	m.step = stepUninstalling
+	m.isUninstall = true
+	m.tasks = m.uninstallPlan.tasks
+
+	m.currentTaskIndex = 0
+	m.tasks[0].status = statusRunning
+	return m, tea.Batch(m.spinner.Tick, executeTaskCmd(0, &m))
+}
+
+func (m model) handleConfirmUninstallKeys(key string) (tea.Model, tea.Cmd) {
+	level := m.uninstallPlan.level
+	switch key {
+	case "1", "2", "3":
+		level = uninstallLevel(key[0] - '1')
+	case "up":
+		if level > uninstallConfig {
+			level--
+		}
+	case "down":
+		if level < uninstallPurge {
+			level++
+		}
+	case "enter", "y":
+		return m.startUninstallation()
+	case "n", "b", "q":
+		m.uninstallPlan = nil
+		m.step = stepWelcome
+		return m, nil
+	}
+	if level != m.uninstallPlan.level {
+		m.uninstallPlan = planUninstall(&m, level)
+	}
+	return m, nil
+}
+
+// Purge tasks
+
+func removeNpmGlobalPackage(m *model) error {
+	cmd := exec.Command("npm", "uninstall", "-g", npmPackage)
+	return runCommand("npm uninstall -g "+npmPackage, cmd, m.logFile)
+}
+
+func removeManagedDir(m *model) error {
+	managedDir, err := getManagedDir()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(managedDir); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", managedDir, err)
+	}
+	return nil
+}
+
+// configBackupCount counts the backups in the backups directory and the
+// legacy ones still next to configPath.
+func configBackupCount(configPath string) (backups, legacy int) {
+	list, _ := listBackups()
+	return len(list), len(legacyBackups(configPath))
+}
+
+func removeConfigBackups(m *model) error {
+	for _, path := range legacyBackups(m.configPath) {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	dir, err := getBackupsDir()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", dir, err)
+	}
+	return nil
+}
+
+// installerLogs lists earlier runs' logs, leaving out this run's.
+func installerLogs(m *model) []string {
+	matches, _ := filepath.Glob(filepath.Join(os.TempDir(), "opencode-cursor-installer-*.log"))
+	var logs []string
+	for _, path := range matches {
+		if m.logFile != nil && path == m.logFile.Name() {
+			continue
+		}
+		logs = append(logs, path)
+	}
+	return logs
+}
+
+func removeInstallerLogs(m *model) error {
+	for _, path := range installerLogs(m) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func removeScheduledSyncTask(m *model) error {
+	_, err := removeScheduledSync()
+	return err
+}
+
+func removeDisabledStash(m *model) error {
+	path, err := stashPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func removeStateDir(m *model) error {
+	stateDir, err := getStateDir()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(stateDir); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", stateDir, err)
+	}
+	return nil
+}
+
+// cmdUninstall is the headless uninstall.
+func cmdUninstall(args []string) error {
+	fs, configPath := newFlagSet("uninstall")
+	levelName := fs.String("level", uninstallPlugin.String(), "what to remove: "+strings.Join(uninstallLevelNames, ", "))
+	dryRun := fs.Bool("dry-run", false, "list what would be removed without removing anything")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	level, err := parseUninstallLevel(*levelName)
+	if err != nil {
+		return err
+	}
+
+	m := newHeadlessModel(*configPath)
+	defer m.cancel()
+	m.isUninstall = true
+
+	plan := planUninstall(&m, level)
+	if *dryRun {
+		fmt.Printf("Uninstall (%s) would remove:\n", level.label())
+		for _, line := range plan.preview {
+			fmt.Printf("  %s\n", line)
+		}
+		return nil
+	}
+
+	for i := range plan.tasks {
+		task := &plan.tasks[i]
+		m.currentTaskIndex = i
+		m.tasks = plan.tasks
+		m.prompt = nil
+		fmt.Printf("%s...\n", task.name)
+		if err := task.execute(&m); err != nil {
+			return fmt.Errorf("%s: %w", task.name, err)
+		}
+		if m.prompt != nil {
+			fmt.Println(m.prompt.title)
+			for _, line := range m.prompt.body {
+				fmt.Printf("  %s\n", line)
+			}
+		}
+	}
+	fmt.Printf("Uninstalled (%s)\n", level.label())
+	return nil
+}
+
+func (m model) renderConfirmUninstall() string {
+	plan := m.uninstallPlan
+	if plan == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(Primary).Render("Uninstall cursor-acp"))
+	b.WriteString("\n\n")
+
+	for level := uninstallConfig; level <= uninstallPurge; level++ {
+		marker := "  "
+		style := lipgloss.NewStyle().Foreground(FgMuted)
+		if level == plan.level {
+			marker = "▸ "
+			style = lipgloss.NewStyle().Bold(true).Foreground(Primary)
+		}
+		b.WriteString(style.Render(fmt.Sprintf("%s[%d] %s", marker, level+1, level.label())))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\nWill remove:\n")
+	if len(plan.preview) == 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(FgMuted).Render("  nothing found"))
+		b.WriteString("\n")
+	}
+	for _, line := range plan.preview {
+		b.WriteString("  • " + line + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(WarningColor).Render("Press Enter to uninstall, n to go back"))
+	return b.String()
+}
@@ -0,0 +1,52 @@
+// cmd/installer/uninstall_test.go
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseUninstallLevel(t *testing.T) {
+	for i, name := range uninstallLevelNames {
+		level, err := parseUninstallLevel(name)
+		if err != nil || level != uninstallLevel(i) || level.String() != name {
+			t.Errorf("parseUninstallLevel(%q) = %v, %v", name, level, err)
+		}
+	}
+	if _, err := parseUninstallLevel("everything"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
+
+func TestPlanUninstallFromManifest(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	root := t.TempDir()
+	configPath := filepath.Join(root, "opencode.json")
+	pluginFile := pluginFilePath(filepath.Join(root, "plugin"))
+
+	err := recordChanges(
+		manifestChange{Kind: changeConfig, Path: configPath, Key: configKeyProvider, Hash: "sha256:x"},
+		manifestChange{Kind: changeFile, Path: pluginFile, Value: "/src/dist/plugin-entry.js"},
+		manifestChange{Kind: changePackage, Path: root, Key: "@ai-sdk/openai-compatible", Via: "bun", Existed: true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &model{configPath: configPath, pluginDir: filepath.Join(root, "plugin")}
+
+	configOnly := planUninstall(m, uninstallConfig)
+	if len(configOnly.preview) != 1 || !strings.Contains(configOnly.preview[0], configKeyProvider) {
+		t.Errorf("config-only preview = %v", configOnly.preview)
+	}
+
+	withPlugin := planUninstall(m, uninstallPlugin)
+	preview := strings.Join(withPlugin.preview, "\n")
+	if len(withPlugin.preview) != 3 || !strings.Contains(preview, pluginFile) || !strings.Contains(preview, "kept: installed before") {
+		t.Errorf("plugin+config preview = %v", withPlugin.preview)
+	}
+	if withPlugin.tasks[0].name != "Revert install" {
+		t.Errorf("first task = %q", withPlugin.tasks[0].name)
+	}
+}
@@ -44,6 +44,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case taskCompleteMsg:
 		return m.handleTaskComplete(msg)
+
+	case devEventMsg:
+		return m.handleDevEvent(msg)
 	}
 
 	return m, nil
@@ -52,6 +55,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	key := msg.String()
 
+	// Dev mode asks whether to restore the previous link before quitting.
+	if m.step == stepDev && m.dev != nil && (key == "ctrl+c" || key == "esc") {
+		return m.handleDevKeys(key)
+	}
+
 	switch key {
 	case "ctrl+c":
 		if m.cancel != nil {
@@ -75,11 +83,19 @@ func (m model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleWelcomeKeys(key)
 	case stepSelectMode:
 		return m.handleSelectModeKeys(key)
+	case stepPrompt:
+		return m.handlePromptKeys(key)
 	case stepInstalling, stepUninstalling:
 		// Can't quit during install/uninstall
 		return m, nil
 	case stepComplete:
 		return m.handleCompleteKeys(key)
+	case stepDev:
+		return m.handleDevKeys(key)
+	case stepConfirmUninstall:
+		return m.handleConfirmUninstallKeys(key)
+	case stepRepair:
+		return m.handleRepairKeys(key)
 	}
 
 	return m, nil
@@ -94,12 +110,34 @@ func (m model) handleWelcomeKeys(key string) (tea.Model, tea.Cmd) {
 				return m, nil // Don't proceed with blocking errors
 			}
 		}
+		if m.installSource != nil {
+			// The source was given on the command line; there is no mode
+			// to choose.
+			return m.startInstallation()
+		}
 		m.step = stepSelectMode
 		return m, nil
 	case "u":
-		// Uninstall - no prerequisites needed
+		// Uninstall - no prerequisites needed; confirm and pick a level first
+		if m.existingSetup || m.disabled {
+			m.uninstallPlan = planUninstall(&m, uninstallPlugin)
+			m.step = stepConfirmUninstall
+			return m, nil
+		}
+	case "g":
+		if m.existingSetup {
+			return m.startUpgrade()
+		}
+	case "r":
 		if m.existingSetup {
-			return m.startUninstallation()
+			m.health = inspectInstall(&m)
+			m.legacy = detectMigrations(&m, false)
+			m.step = stepRepair
+			return m, nil
+		}
+	case "t":
+		if m.existingSetup || m.disabled {
+			m.toggleFromWelcome()
 		}
 	}
 	return m, nil
@@ -113,6 +151,22 @@ func (m model) handleSelectModeKeys(key string) (tea.Model, tea.Cmd) {
 	case "2", "s":
 		m.mode = modeBuildFromSource
 		return m.startInstallingFromMode()
+	case "3", "d":
+		if isPluginCheckout(m.projectDir) {
+			return m.startDevMode()
+		}
+	case "p":
+		m.probeModels = !m.probeModels
+	case "m":
+		m.chooseDefaults = !m.chooseDefaults
+	case "t":
+		m.runTests = !m.runTests
+	case "b":
+		m.snapshot = !m.snapshot
+	case "l":
+		m.linkMode = nextLinkMode(m.linkMode)
+	case "v":
+		m.npmTag = nextNpmTag(m.npmTag, m.installedVersion)
 	}
 	return m, nil
 }
@@ -128,6 +182,61 @@ func (m model) startInstallingFromMode() (tea.Model, tea.Cmd) {
 	}
 }
 
+func (m model) handlePromptKeys(key string) (tea.Model, tea.Cmd) {
+	if m.prompt == nil {
+		return m, nil
+	}
+
+	switch key {
+	case "up":
+		if m.prompt.cursor > 0 {
+			m.prompt.cursor--
+		}
+		return m, nil
+	case "down":
+		if m.prompt.cursor < len(m.prompt.choices)-1 {
+			m.prompt.cursor++
+		}
+		return m, nil
+	}
+
+	for _, opt := range m.prompt.options {
+		if opt.key != key {
+			continue
+		}
+
+		choice := ""
+		if len(m.prompt.choices) > 0 {
+			choice = m.prompt.choices[m.prompt.cursor]
+		}
+
+		m.prompt = nil
+		if opt.apply != nil {
+			if err := opt.apply(&m, choice); err != nil {
+				task := &m.tasks[m.currentTaskIndex]
+				task.status = statusFailed
+				task.errorDetails = &errorInfo{message: err.Error(), logFile: m.logFile.Name()}
+				if !task.optional {
+					m.errors = append(m.errors, err.Error())
+					m.step = stepComplete
+					return m, nil
+				}
+				m.prompt = nil
+			}
+		}
+		if m.prompt != nil {
+			return m, nil
+		}
+
+		m.step = stepInstalling
+		if m.isUninstall {
+			m.step = stepUninstalling
+		}
+		return m.advanceTask()
+	}
+	return m, nil
+}
+
 func (m model) handleCompleteKeys(key string) (tea.Model, tea.Cmd) {
 	if key == "enter" || key == "q" {
 		return m, tea.Quit
@@ -0,0 +1,504 @@
+// cmd/installer/upgrade.go
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
+)
+
+// pluginSource is where the plugin OpenCode loads comes from.
+type pluginSource int
+
+const (
+	sourceUnknown pluginSource = iota
+	sourceNpmGlobal
+	sourceLocalBuild
+	sourceOpenCodeCache
+	sourceBunGlobal
+	sourceManagedCopy
+)
+
+func (s pluginSource) String() string {
+	switch s {
+	case sourceNpmGlobal:
+		return "npm global install"
+	case sourceLocalBuild:
+		return "local build"
+	case sourceOpenCodeCache:
+		return "OpenCode plugin cache"
+	case sourceBunGlobal:
+		return "bun global install"
+	case sourceManagedCopy:
+		return "installer-managed copy"
+	default:
+		return "unknown"
+	}
+}
+
+func (s pluginSource) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// installedPlugin is the result of detectInstalledPlugin.
+type installedPlugin struct {
+	source  pluginSource
+	version string
+	dir     string // package root holding package.json
+}
+
+// Upgrade task names, referenced when skipping steps that aren't needed.
+const (
+	taskUpgradeNpm    = "Install npm package"
+	taskUpgradeBuild  = "Rebuild plugin"
+	taskUpgradeConfig = "Update config"
+	taskUpgradeCache  = "Clear OpenCode cache"
+)
+
+const (
+	changelogMaxLines  = 24
+	npmViewTimeout     = 15 * time.Second
+	packageSearchDepth = 4
+)
+
+func (m model) startUpgrade() (tea.Model, tea.Cmd) {
+	m.step = stepInstalling
+	m.isUpgrade = true
+
+	m.tasks = []installTask{
+		{name: "Check versions", description: "Comparing installed and target versions", execute: checkUpgrade, status: statusPending},
+		{name: taskUpgradeNpm, description: fmt.Sprintf("npm install -g %s@%s", npmPackage, m.npmTag), execute: upgradeNpmPackage, status: statusPending},
+		{name: taskUpgradeBuild, description: "Rebuilding the local checkout", execute: rebuildLocalPlugin, status: statusPending},
+		{name: taskUpgradeConfig, description: "Pointing the plugin entry at the target version", execute: updatePluginEntryVersion, status: statusPending},
+		{name: taskUpgradeCache, description: "Removing OpenCode's cached copy of the plugin", execute: clearOpenCodePluginCache, status: statusPending},
+		{name: "Verify plugin loads", description: "Checking if plugin appears in opencode", execute: verifyPostInstall, optional: true, status: statusPending},
+	}
+
+	m.currentTaskIndex = 0
+	m.tasks[0].status = statusRunning
+	return m, tea.Batch(m.spinner.Tick, executeTaskCmd(0, &m))
+}
+
+// detectInstalledPlugin finds the plugin OpenCode currently loads: the
+// package behind the cursor-acp.js symlink (or, for a copy, the entry the
+// manifest recorded), else the npm global install, else the copy OpenCode
+// installed from the plugin array.
+func detectInstalledPlugin(m *model) installedPlugin {
+	npmRoot := npmGlobalRoot()
+
+	target, err := filepath.EvalSymlinks(pluginFilePath(m.pluginDir))
+	if err == nil && detectLinkMode(pluginFilePath(m.pluginDir)) == linkModeCopy {
+		target = ""
+		if mf, err := loadInstallManifest(); err == nil && mf.PluginEntry != "" {
+			target = mf.PluginEntry
+		}
+	}
+	if err == nil && target != "" {
+		if dir, version := findPackageRoot(filepath.Dir(target)); dir != "" {
+			source := sourceLocalBuild
+			if npmRoot != "" && strings.HasPrefix(dir, npmRoot+string(filepath.Separator)) {
+				source = sourceNpmGlobal
+			}
+			return installedPlugin{source: source, version: version, dir: dir}
+		}
+	}
+
+	if npmRoot != "" {
+		dir := filepath.Join(npmRoot, filepath.FromSlash(npmPackage))
+		if version, err := readPackageVersion(filepath.Join(dir, "package.json")); err == nil {
+			return installedPlugin{source: sourceNpmGlobal, version: version, dir: dir}
+		}
+	}
+
+	if modulesDir, err := getOpenCodeModulesDir(); err == nil {
+		dir := filepath.Join(modulesDir, filepath.FromSlash(npmPackage))
+		if version, err := readPackageVersion(filepath.Join(dir, "package.json")); err == nil {
+			return installedPlugin{source: sourceOpenCodeCache, version: version, dir: dir}
+		}
+	}
+
+	return installedPlugin{}
+}
+
+// findPackageRoot walks up from dir to the package.json of this plugin.
+func findPackageRoot(dir string) (root, version string) {
+	for i := 0; i < packageSearchDepth; i++ {
+		path := filepath.Join(dir, "package.json")
+		if data, err := os.ReadFile(path); err == nil && strings.Contains(string(data), `"`+npmPackage+`"`) {
+			if version, err := readPackageVersion(path); err == nil {
+				return dir, version
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return "", ""
+}
+
+// resolveNpmVersion asks the registry which version a tag points at. Exact
+// versions are returned as is; "" means the registry couldn't be reached.
+func resolveNpmVersion(tag string) string {
+	if isPinnedVersion(tag) {
+		return tag
+	}
+	if !commandExists("npm") {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), npmViewTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "npm", "view", npmPackage+"@"+tag, "version").Output()
+	if err != nil {
+		return ""
+	}
+	lines := strings.Fields(string(out))
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Trim(lines[len(lines)-1], "'\"")
+}
+
+// checkUpgrade is the first upgrade task. It works out the installed and
+// target versions, skips the steps this kind of install doesn't need, and
+// shows the CHANGELOG entries in between before anything changes.
+func checkUpgrade(m *model) error {
+	installed := detectInstalledPlugin(m)
+	if installed.source == sourceUnknown {
+		return fmt.Errorf("no installed cursor-acp plugin found - use Install instead")
+	}
+
+	var target string
+	switch installed.source {
+	case sourceLocalBuild:
+		// A local build is only rebuilt from what the checkout holds; the
+		// installer never moves it to another tag, so there's no target
+		// version to compare against.
+		m.skipTasks = []string{taskUpgradeNpm, taskUpgradeConfig}
+	case sourceNpmGlobal:
+		target = resolveNpmVersion(m.npmTag)
+		m.skipTasks = []string{taskUpgradeBuild}
+	case sourceOpenCodeCache:
+		target = resolveNpmVersion(m.npmTag)
+		m.skipTasks = []string{taskUpgradeNpm, taskUpgradeBuild}
+	}
+	if installed.source != sourceOpenCodeCache && !configHasNpmEntry(m.configPath) {
+		m.skipTasks = appendUnique(m.skipTasks, taskUpgradeConfig)
+	}
+
+	targetLabel := target
+	if targetLabel == "" {
+		targetLabel = m.npmTag + " (registry unreachable)"
+	}
+	body := []string{
+		fmt.Sprintf("Installed: %s (%s, %s)", installed.version, installed.source, installed.dir),
+	}
+	if installed.source == sourceLocalBuild {
+		m.prompt = &taskPrompt{
+			title: fmt.Sprintf("Rebuild cursor-acp %s from %s", installed.version, installed.dir),
+			body: append(body,
+				"",
+				"Local builds are only rebuilt from the checkout as it is; "+m.npmTag+" is not applied.",
+				"To move to another version, pull or check out its tag in "+installed.dir+" first."),
+			options: []promptOption{
+				{key: "enter", label: "Rebuild"},
+				{key: "c", label: "Cancel", apply: cancelRemainingTasks},
+			},
+		}
+		return nil
+	}
+	body = append(body, fmt.Sprintf("Target:    %s", targetLabel))
+
+	cmp := 0
+	if target != "" {
+		cmp = compareVersions(installed.version, target)
+	}
+	if target != "" && cmp == 0 {
+		m.prompt = &taskPrompt{
+			title: fmt.Sprintf("cursor-acp is already at %s", installed.version),
+			body:  body,
+			options: []promptOption{
+				{key: "enter", label: "Reinstall anyway"},
+				{key: "c", label: "Cancel", apply: cancelRemainingTasks},
+			},
+		}
+		return nil
+	}
+
+	if notes := changelogBetween(changelogPath(m, installed), installed.version, target); len(notes) > 0 {
+		body = append(body, "")
+		body = append(body, notes...)
+	}
+
+	title := fmt.Sprintf("Upgrade cursor-acp %s → %s", installed.version, targetLabel)
+	if cmp > 0 {
+		title = fmt.Sprintf("Downgrade cursor-acp %s → %s", installed.version, targetLabel)
+	}
+
+	m.prompt = &taskPrompt{
+		title: title,
+		body:  body,
+		options: []promptOption{
+			{key: "enter", label: "Upgrade"},
+			{key: "c", label: "Cancel", apply: cancelRemainingTasks},
+		},
+	}
+	return nil
+}
+
+// cancelRemainingTasks skips every task after the current one.
+func cancelRemainingTasks(m *model, _ string) error {
+	for i := m.currentTaskIndex + 1; i < len(m.tasks); i++ {
+		if m.tasks[i].status == statusPending {
+			m.tasks[i].status = statusSkipped
+		}
+	}
+	return nil
+}
+
+func appendUnique(list []string, item string) []string {
+	for _, existing := range list {
+		if existing == item {
+			return list
+		}
+	}
+	return append(list, item)
+}
+
+func configHasNpmEntry(configPath string) bool {
+	config, err := opencodeconfig.Load(configPath)
+	if err != nil {
+		return false
+	}
+	return config.HasPlugin(func(e opencodeconfig.PluginEntry) bool {
+		return e.Kind() == opencodeconfig.PluginNPM
+	})
+}
+
+// changelogPath prefers the checkout the installer runs from, which is at
+// least as new as any published version, over the installed package's copy.
+func changelogPath(m *model, installed installedPlugin) string {
+	for _, dir := range []string{m.projectDir, installed.dir} {
+		path := filepath.Join(dir, "CHANGELOG.md")
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+var changelogHeadingRegex = regexp.MustCompile(`^## \[([^\]]+)\]`)
+
+// changelogBetween returns the CHANGELOG.md sections for versions newer than
+// from and, when to is set, no newer than to. Output is capped at
+// changelogMaxLines.
+func changelogBetween(path, from, to string) []string {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []string
+	include := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := changelogHeadingRegex.FindStringSubmatch(line); match != nil {
+			version := match[1]
+			include = semverRegex.MatchString(version) &&
+				compareVersions(version, from) > 0 &&
+				(to == "" || compareVersions(version, to) <= 0)
+		} else if strings.HasPrefix(line, "# ") {
+			include = false
+		}
+		if include && strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) > changelogMaxLines {
+		more := len(lines) - changelogMaxLines
+		lines = append(lines[:changelogMaxLines], fmt.Sprintf("… %d more lines in %s", more, path))
+	}
+	return lines
+}
+
+// compareVersions orders semver strings; prereleases sort before the release
+// and are ordered by comparePrerelease. Unparseable input sorts first.
+func compareVersions(a, b string) int {
+	parse := func(v string) ([3]int, string, bool) {
+		var nums [3]int
+		v = strings.TrimPrefix(v, "v")
+		v, _, _ = strings.Cut(v, "+")
+		core, pre, _ := strings.Cut(v, "-")
+		parts := strings.Split(core, ".")
+		if len(parts) != 3 {
+			return nums, "", false
+		}
+		for i, p := range parts {
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return nums, "", false
+			}
+			nums[i] = n
+		}
+		return nums, pre, true
+	}
+
+	an, apre, aok := parse(a)
+	bn, bpre, bok := parse(b)
+	switch {
+	case !aok && !bok:
+		return strings.Compare(a, b)
+	case !aok:
+		return -1
+	case !bok:
+		return 1
+	}
+	for i := range an {
+		if an[i] != bn[i] {
+			if an[i] < bn[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case apre == bpre:
+		return 0
+	case apre == "":
+		return 1
+	case bpre == "":
+		return -1
+	}
+	return comparePrerelease(apre, bpre)
+}
+
+// comparePrerelease orders prerelease tags the way semver does: identifier
+// by identifier, numerically when both are numbers (so rc.9 < rc.10), with
+// numbers before words and a shorter tag before a longer one it prefixes.
+func comparePrerelease(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+func upgradeNpmPackage(m *model) error {
+	spec := fmt.Sprintf("%s@%s", npmPackage, m.npmTag)
+	existed := installedNpmVersion() != ""
+	cmd := exec.Command("npm", "install", "-g", spec)
+	if err := runCommand("npm install -g "+spec, cmd, m.logFile); err != nil {
+		return err
+	}
+	if err := recordChanges(manifestChange{Kind: changePackage, Key: npmPackage, Via: "npm -g", Existed: existed}); err != nil {
+		return err
+	}
+
+	installed := detectInstalledPlugin(m)
+	if installed.source != sourceNpmGlobal {
+		return fmt.Errorf("%s installed but the plugin symlink no longer resolves to it", spec)
+	}
+	return nil
+}
+
+func rebuildLocalPlugin(m *model) error {
+	installed := detectInstalledPlugin(m)
+	if installed.source != sourceLocalBuild {
+		return fmt.Errorf("plugin symlink does not point at a local build")
+	}
+	if err := buildPluginFromSource(m, installed.dir); err != nil {
+		return err
+	}
+	// A copy doesn't follow the rebuild; refresh it and its recorded hash
+	// so uninstall still recognises it.
+	path := pluginFilePath(m.pluginDir)
+	if detectLinkMode(path) != linkModeCopy {
+		return nil
+	}
+	if err := linkPluginFile(m.pluginEntry, path, linkModeCopy); err != nil {
+		return err
+	}
+	change, err := pluginFileChange(path, true, "")
+	if err != nil {
+		return err
+	}
+	return recordChanges(change)
+}
+
+// updatePluginEntryVersion rewrites npm plugin entries to the target tag so
+// OpenCode resolves the new version.
+func updatePluginEntryVersion(m *model) error {
+	_ = backupConfigToDisk(m.configPath, m.currentTaskName())
+	if err := createBackup(m, m.configPath); err != nil {
+		return fmt.Errorf("failed to backup config: %w", err)
+	}
+
+	config, err := opencodeconfig.Load(m.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	before := config.Clone()
+	spec := npmPackage + "@" + m.npmTag
+	for i, p := range config.Plugin {
+		if p.Kind() == opencodeconfig.PluginNPM {
+			config.Plugin[i] = opencodeconfig.Plugin(spec)
+		}
+	}
+	return saveTrackedConfig(m.configPath, before, config)
+}
+
+// clearOpenCodePluginCache removes OpenCode's node_modules copy of the plugin;
+// OpenCode otherwise keeps loading the old version.
+func clearOpenCodePluginCache(m *model) error {
+	modulesDir, err := getOpenCodeModulesDir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(modulesDir, filepath.FromSlash(npmPackage))
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+// cmd/installer/upgrade_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"2.3.5", "2.3.10", -1},
+		{"2.3.10", "2.3.5", 1},
+		{"2.3.5", "2.3.5", 0},
+		{"2.4.0-rc.1", "2.4.0", -1},
+		{"2.4.0-rc.9", "2.4.0-rc.10", -1},
+		{"2.4.0-rc.10", "2.4.0-rc.9", 1},
+		{"2.4.0-alpha", "2.4.0-alpha.1", -1},
+		{"2.4.0-alpha.1", "2.4.0-alpha.beta", -1},
+		{"2.4.0-beta.2", "2.4.0-alpha.10", 1},
+		{"v2.4.0", "2.4.0", 0},
+		{"unknown", "0.0.1", -1},
+	}
+	for _, c := range cases {
+		if got := compareVersions(c.a, c.b); got != c.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestChangelogBetween(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "CHANGELOG.md")
+	changelog := `# Changelog
+
+## [Unreleased]
+- not yet
+
+## [2.3.10] - 2026-03-01
+
+### Fixed
+- ten
+
+## [2.3.6] - 2026-02-20
+- six
+
+## [2.3.5] - 2026-02-17
+- five
+`
+	if err := os.WriteFile(path, []byte(changelog), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := changelogBetween(path, "2.3.5", "2.3.6")
+	want := []string{"## [2.3.6] - 2026-02-20", "- six"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changelogBetween = %q, want %q", got, want)
+	}
+
+	got = changelogBetween(path, "2.3.5", "")
+	if len(got) != 5 || got[0] != "## [2.3.10] - 2026-03-01" {
+		t.Errorf("open-ended changelogBetween = %q", got)
+	}
+}
+
+func TestFindPackageRoot(t *testing.T) {
+	root := t.TempDir()
+	pkg := `{"name": "` + npmPackage + `", "version": "2.3.9"}`
+	if err := os.WriteFile(filepath.Join(root, "package.json"), []byte(pkg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dist := filepath.Join(root, "dist")
+	if err := os.MkdirAll(dist, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, version := findPackageRoot(dist)
+	if dir != root || version != "2.3.9" {
+		t.Errorf("findPackageRoot = %q, %q", dir, version)
+	}
+}
+
+func TestCopyUpgradeUninstall(t *testing.T) {
+	m := newTestModel(t)
+	m.linkMode = linkModeCopy
+	pkg := `{"name": "` + npmPackage + `", "version": "2.3.10"}`
+	if err := os.WriteFile(filepath.Join(m.projectDir, "package.json"), []byte(pkg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m.pluginEntry = writePluginEntry(t, m.projectDir)
+	if err := recordInstallSource(manifestSource{Kind: "build", Location: m.projectDir}, m.pluginEntry); err != nil {
+		t.Fatal(err)
+	}
+	if err := createSymlink(m); err != nil {
+		t.Fatal(err)
+	}
+
+	// The rebuild changes the entry, so the copy must be refreshed.
+	bin := t.TempDir()
+	bun := "#!/bin/sh\n[ \"$1\" = run ] && echo 'export default { rebuilt: true }' > dist/plugin-entry.js\nexit 0\n"
+	if err := os.WriteFile(filepath.Join(bin, "bun"), []byte(bun), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+"/bin"+string(os.PathListSeparator)+"/usr/bin")
+	if err := rebuildLocalPlugin(m); err != nil {
+		t.Fatal(err)
+	}
+	path := pluginFilePath(m.pluginDir)
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "rebuilt") {
+		t.Fatalf("copy not refreshed: %q", data)
+	}
+
+	mf, err := loadInstallManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := revertChanges(m, mf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.modified) != 0 {
+		t.Errorf("modified = %v, want the refreshed copy removed", report.modified)
+	}
+	if pathExists(path) {
+		t.Error("plugin copy left behind")
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/nomadcxx/opencode-cursor/pkg/opencodeconfig"
 )
 
 // getConfigDir returns ~/.config for the actual user
@@ -55,23 +57,12 @@ func detectExistingSetup() (bool, string) {
 	}
 
 	// Check config file
-	data, err := os.ReadFile(configPath)
+	config, err := opencodeconfig.Load(configPath)
 	if err != nil {
 		return false, configPath
 	}
 
-	var config map[string]interface{}
-	if err := json.Unmarshal(data, &config); err != nil {
-		return false, configPath
-	}
-
-	if providers, ok := config["provider"].(map[string]interface{}); ok {
-		if _, exists := providers["cursor-acp"]; exists {
-			return true, configPath
-		}
-	}
-
-	return false, configPath
+	return config.CursorACP() != nil, configPath
 }
 
 // commandExists checks if a command is available
@@ -277,3 +268,62 @@ func getProjectDir() string {
 	}
 	return filepath.Dir(exe)
 }
+
+// getDataDir returns ~/.local/share (or $XDG_DATA_HOME) for the actual user
+func getDataDir() (string, error) {
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		return dataHome, nil
+	}
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configDir), ".local", "share"), nil
+}
+
+// getCacheDir returns ~/.cache (or $XDG_CACHE_HOME) for the actual user
+func getCacheDir() (string, error) {
+	if cacheHome := os.Getenv("XDG_CACHE_HOME"); cacheHome != "" {
+		return cacheHome, nil
+	}
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configDir), ".cache"), nil
+}
+
+// getOpenCodeModulesDir returns the node_modules OpenCode installs plugin
+// array packages into (~/.cache/opencode/node_modules).
+func getOpenCodeModulesDir() (string, error) {
+	cacheDir, err := getCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "opencode", "node_modules"), nil
+}
+
+// getStateDir returns the installer's state directory
+// (~/.local/state/opencode-cursor or $XDG_STATE_HOME/opencode-cursor)
+func getStateDir() (string, error) {
+	if stateHome := os.Getenv("XDG_STATE_HOME"); stateHome != "" {
+		return filepath.Join(stateHome, "opencode-cursor"), nil
+	}
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configDir), ".local", "state", "opencode-cursor"), nil
+}
+
+// ensureStateDir returns the state directory, creating it if needed
+func ensureStateDir() (string, error) {
+	stateDir, err := getStateDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create state directory: %w", err)
+	}
+	return stateDir, nil
+}
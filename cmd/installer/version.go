@@ -0,0 +1,122 @@
+// cmd/installer/version.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// npmChannels are the dist-tags the plugin is published under.
+var npmChannels = []string{"latest", "next"}
+
+var semverRegex = regexp.MustCompile(`^\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+// parseNpmTag accepts a release channel or an exact version ("v" prefix
+// allowed) and returns it as used after "@" in an npm spec.
+func parseNpmTag(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	for _, channel := range npmChannels {
+		if s == channel {
+			return s, nil
+		}
+	}
+	if v := strings.TrimPrefix(s, "v"); semverRegex.MatchString(v) {
+		return v, nil
+	}
+	return "", fmt.Errorf("invalid version %q: expected %s or an exact version like 2.3.9", s, strings.Join(npmChannels, ", "))
+}
+
+// isPinnedVersion reports whether tag is an exact version rather than a channel.
+func isPinnedVersion(tag string) bool {
+	return semverRegex.MatchString(tag)
+}
+
+// versionChoices lists what the mode screen cycles through: the channels, the
+// currently installed version (to pin it) and whatever was passed in.
+func versionChoices(current, installed string) []string {
+	choices := append([]string{}, npmChannels...)
+	for _, extra := range []string{installed, current} {
+		if extra == "" {
+			continue
+		}
+		known := false
+		for _, c := range choices {
+			if c == extra {
+				known = true
+				break
+			}
+		}
+		if !known {
+			choices = append(choices, extra)
+		}
+	}
+	return choices
+}
+
+// nextNpmTag returns the choice after current, wrapping around.
+func nextNpmTag(current, installed string) string {
+	choices := versionChoices(current, installed)
+	for i, c := range choices {
+		if c == current {
+			return choices[(i+1)%len(choices)]
+		}
+	}
+	return choices[0]
+}
+
+// npmGlobalRoot returns `npm root -g`, or "" when npm is unavailable.
+func npmGlobalRoot() string {
+	if !commandExists("npm") {
+		return ""
+	}
+	out, err := exec.Command("npm", "root", "-g").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// readPackageVersion returns the "version" field of a package.json.
+func readPackageVersion(path string) (string, error) {
+	_, version, err := readPackageIdentity(path)
+	return version, err
+}
+
+// readPackageIdentity returns the "name" and "version" fields of a
+// package.json.
+func readPackageIdentity(path string) (name, version string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	var pkg struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return "", "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if pkg.Version == "" {
+		return "", "", fmt.Errorf("%s has no version", path)
+	}
+	return pkg.Name, pkg.Version, nil
+}
+
+// installedNpmVersion returns the version of the globally installed npm
+// package, or "" when it isn't installed.
+func installedNpmVersion() string {
+	root := npmGlobalRoot()
+	if root == "" {
+		return ""
+	}
+	version, err := readPackageVersion(filepath.Join(root, filepath.FromSlash(npmPackage), "package.json"))
+	if err != nil {
+		return ""
+	}
+	return version
+}
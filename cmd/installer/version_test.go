@@ -0,0 +1,40 @@
+// cmd/installer/version_test.go
+package main
+
+import "testing"
+
+func TestParseNpmTag(t *testing.T) {
+	for input, want := range map[string]string{
+		"latest":     "latest",
+		"next":       "next",
+		"2.3.9":      "2.3.9",
+		"v2.3.9":     "2.3.9",
+		"2.4.0-rc.1": "2.4.0-rc.1",
+		" 2.3.9 ":    "2.3.9",
+	} {
+		got, err := parseNpmTag(input)
+		if err != nil || got != want {
+			t.Errorf("parseNpmTag(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "beta", "2.3", "^2.3.9"} {
+		if _, err := parseNpmTag(input); err == nil {
+			t.Errorf("parseNpmTag(%q) accepted", input)
+		}
+	}
+}
+
+func TestNextNpmTag(t *testing.T) {
+	tag := "latest"
+	var seen []string
+	for i := 0; i < 4; i++ {
+		tag = nextNpmTag(tag, "2.3.9")
+		seen = append(seen, tag)
+	}
+	want := []string{"next", "2.3.9", "latest", "next"}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Fatalf("cycle = %v, want %v", seen, want)
+		}
+	}
+}
@@ -75,10 +75,18 @@ func (m model) View() string {
 		mainContent = m.renderSelectMode()
 	case stepInstalling:
 		mainContent = m.renderInstalling()
+	case stepPrompt:
+		mainContent = m.renderPrompt()
 	case stepUninstalling:
 		mainContent = m.renderInstalling() // Same view for uninstalling
 	case stepComplete:
 		mainContent = m.renderComplete()
+	case stepDev:
+		mainContent = m.renderDev()
+	case stepConfirmUninstall:
+		mainContent = m.renderConfirmUninstall()
+	case stepRepair:
+		mainContent = m.renderRepair()
 	}
 
 	mainStyle := lipgloss.NewStyle().
@@ -117,16 +125,44 @@ func (m model) View() string {
 func (m model) getHelpText() string {
 	switch m.step {
 	case stepWelcome:
+		if m.disabled {
+			return "Enter: Install  •  t: Enable  •  u: Uninstall  •  q: Quit"
+		}
 		if m.existingSetup {
-			return "Enter: Install  •  u: Uninstall  •  q: Quit"
+			return "Enter: Install  •  g: Upgrade  •  r: Repair  •  t: Disable  •  u: Uninstall  •  q: Quit"
 		}
 		return "Enter: Install  •  q: Quit"
 	case stepSelectMode:
-		return "Press 1 or 2 to continue"
+		return "Press 1 or 2 to continue  •  p: Toggle model probing  •  t: Toggle tests  •  l: Link mode  •  v: Change version"
 	case stepInstalling, stepUninstalling:
 		return "Please wait..."
+	case stepPrompt:
+		if m.prompt == nil {
+			return ""
+		}
+		keys := make([]string, 0, len(m.prompt.options))
+		for _, opt := range m.prompt.options {
+			key := opt.key
+			if key == "enter" {
+				key = "Enter"
+			}
+			keys = append(keys, fmt.Sprintf("%s: %s", key, opt.label))
+		}
+		if len(m.prompt.choices) > 0 {
+			keys = append([]string{"↑/↓: Select"}, keys...)
+		}
+		return strings.Join(keys, "  •  ")
 	case stepComplete:
 		return "Enter: Exit"
+	case stepConfirmUninstall:
+		return "1-3/↑↓: Choose level  •  Enter: Uninstall  •  n: Back"
+	case stepRepair:
+		return "Enter: Fix  •  n: Back"
+	case stepDev:
+		if m.dev != nil && m.dev.quitting {
+			return "y: Restore  •  n: Keep dev link"
+		}
+		return "q: Stop watching"
 	}
 	return ""
 }
@@ -153,11 +189,32 @@ func (m model) renderWelcome() string {
 
 	b.WriteString("\n")
 
-	if m.existingSetup {
+	if m.installSource != nil {
+		b.WriteString(fmt.Sprintf("Installing from %s\n\n", m.installSource))
+	}
+
+	if m.notice != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(Secondary).Render(m.notice))
+		b.WriteString("\n\n")
+	}
+
+	if m.disabled {
+		b.WriteString(lipgloss.NewStyle().Foreground(WarningColor).Render("⚠ cursor-acp is disabled (provider and plugin entry stashed)"))
+		b.WriteString("\n\n")
+		b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(Primary).Render("Press 't' to enable"))
+		b.WriteString("  •  ")
+		b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(ErrorColor).Render("Press 'u' to uninstall"))
+	} else if m.existingSetup {
 		b.WriteString(lipgloss.NewStyle().Foreground(WarningColor).Render("⚠ cursor-acp already configured"))
 		b.WriteString("\n\n")
 		b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(Primary).Render("Press Enter to reinstall"))
 		b.WriteString("  •  ")
+		b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(Primary).Render("Press 'g' to upgrade"))
+		b.WriteString("  •  ")
+		b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(Primary).Render("Press 'r' to repair"))
+		b.WriteString("  •  ")
+		b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(Primary).Render("Press 't' to disable"))
+		b.WriteString("  •  ")
 		b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(ErrorColor).Render("Press 'u' to uninstall"))
 	} else {
 		// Check if we can proceed
@@ -180,13 +237,59 @@ func (m model) renderWelcome() string {
 }
 
 func (m model) renderSelectMode() string {
+	probe := "off"
+	if m.probeModels {
+		probe = "on"
+	}
+	defaults := "off"
+	if m.chooseDefaults {
+		defaults = "on"
+	}
+	tests := "off"
+	if m.runTests {
+		tests = "on"
+	}
+	snapshot := "off"
+	if m.snapshot {
+		snapshot = "on"
+	}
+
+	version := m.npmTag
+	switch {
+	case isPinnedVersion(version) && version == m.installedVersion:
+		version += " (pinned to installed)"
+	case isPinnedVersion(version):
+		version += " (pinned)"
+	case m.installedVersion != "":
+		version += " (installed: " + m.installedVersion + ")"
+	}
+
+	devOption := ""
+	if isPluginCheckout(m.projectDir) {
+		devOption = "  [3] Dev link (contributors)\n" +
+			"      Links this checkout's dist/ and rebuilds on change (bun run dev).\n\n"
+	}
+
 	return "Choose installation method:\n\n" +
 		"  [1] Quick Install (recommended)\n" +
 		"      Adds the npm package to your opencode.json plugin array.\n" +
 		"      Fastest — no building required.\n\n" +
 		"  [2] Build from Source\n" +
-		"      Clones, builds, and symlinks the plugin locally.\n" +
+		"      Builds this checkout, or clones a chosen branch/tag, and symlinks it.\n" +
 		"      Use if you need to modify the source code.\n\n" +
+		devOption +
+		"  [p] Probe models after install: " + probe + "\n" +
+		"      Sends a test request to each model and offers to drop unusable ones.\n\n" +
+		"  [m] Choose default models: " + defaults + "\n" +
+		"      Offers the cursor-acp models for opencode.json model and small_model.\n\n" +
+		"  [t] Run unit tests after build: " + tests + "\n" +
+		"      Build from Source only; if tests fail you choose whether to link the build.\n\n" +
+		"  [b] Snapshot config dir first: " + snapshot + "\n" +
+		"      Archives package.json, the lockfile and plugin/; undo with `installer restore-snapshot`.\n\n" +
+		"  [l] Plugin file: " + m.linkMode + "\n" +
+		"      Build from Source only; relative suits dotfile repos, copy suits synced or container config dirs.\n\n" +
+		"  [v] Plugin version: " + version + "\n" +
+		"      Cycles latest, next and the installed version; used for npm install and the plugin entry.\n\n" +
 		"Press 1 or 2 to continue."
 }
 
@@ -211,8 +314,7 @@ func (m model) renderInstalling() string {
 
 		if task.status == statusFailed && task.errorDetails != nil {
 			err := task.errorDetails
-			b.WriteString(lipgloss.NewStyle().Foreground(ErrorColor).Render(
-				fmt.Sprintf("  └─ Error: %s\n", err.message)))
+			b.WriteString(renderErrorMessage(err.message))
 			if err.logFile != "" {
 				b.WriteString(lipgloss.NewStyle().Foreground(FgMuted).Render(
 					fmt.Sprintf("  └─ See logs: %s\n", err.logFile)))
@@ -231,6 +333,68 @@ func (m model) renderInstalling() string {
 	return b.String()
 }
 
+func (m model) renderPrompt() string {
+	var b strings.Builder
+
+	b.WriteString(m.renderInstalling())
+	b.WriteString("\n")
+
+	if m.prompt == nil {
+		return b.String()
+	}
+
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(Primary).Render(m.prompt.title))
+	b.WriteString("\n\n")
+	for _, line := range m.prompt.body {
+		b.WriteString("  " + line + "\n")
+	}
+	if len(m.prompt.choices) > 0 {
+		if len(m.prompt.body) > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(m.renderPromptChoices())
+	}
+	b.WriteString("\n")
+	for _, opt := range m.prompt.options {
+		b.WriteString(fmt.Sprintf("  [%s] %s\n", opt.key, opt.label))
+	}
+
+	return b.String()
+}
+
+// renderPromptChoices shows a window of the prompt's choices around the cursor.
+func (m model) renderPromptChoices() string {
+	const window = 8
+	choices := m.prompt.choices
+	start := m.prompt.cursor - window/2
+	if start > len(choices)-window {
+		start = len(choices) - window
+	}
+	if start < 0 {
+		start = 0
+	}
+	end := start + window
+	if end > len(choices) {
+		end = len(choices)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(FgMuted).Render(fmt.Sprintf("    ↑ %d more", start)) + "\n")
+	}
+	for i := start; i < end; i++ {
+		if i == m.prompt.cursor {
+			b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(Primary).Render("  > "+choices[i]) + "\n")
+		} else {
+			b.WriteString("    " + choices[i] + "\n")
+		}
+	}
+	if end < len(choices) {
+		b.WriteString(lipgloss.NewStyle().Foreground(FgMuted).Render(fmt.Sprintf("    ↓ %d more", len(choices)-end)) + "\n")
+	}
+	return b.String()
+}
+
 func (m model) renderComplete() string {
 	hasCriticalFailure := false
 	for _, task := range m.tasks {
@@ -266,8 +430,7 @@ func (m model) renderComplete() string {
 
 			if task.status == statusFailed && task.errorDetails != nil {
 				err := task.errorDetails
-				b.WriteString(lipgloss.NewStyle().Foreground(ErrorColor).Render(
-					fmt.Sprintf("  └─ Error: %s\n", err.message)))
+				b.WriteString(renderErrorMessage(err.message))
 				if err.logFile != "" {
 					b.WriteString(lipgloss.NewStyle().Foreground(FgMuted).Render(
 						fmt.Sprintf("  └─ Logs: %s\n", err.logFile)))
@@ -289,6 +452,14 @@ func (m model) renderComplete() string {
 		b.WriteString(lipgloss.NewStyle().Foreground(SuccessColor).Bold(true).Render("✓ Uninstallation Complete"))
 		b.WriteString("\n\n")
 		b.WriteString("The cursor-acp plugin has been removed from OpenCode.\n\n")
+	} else if m.isUpgrade {
+		b.WriteString(lipgloss.NewStyle().Foreground(SuccessColor).Bold(true).Render("✓ Upgrade Complete"))
+		b.WriteString("\n\n")
+		b.WriteString("Restart OpenCode to load the new plugin version.\n\n")
+	} else if m.isRepair {
+		b.WriteString(lipgloss.NewStyle().Foreground(SuccessColor).Bold(true).Render("✓ Repair Complete"))
+		b.WriteString("\n\n")
+		b.WriteString("Restart OpenCode to pick up the fixes.\n\n")
 	} else {
 		b.WriteString(lipgloss.NewStyle().Foreground(SuccessColor).Bold(true).Render("✓ Installation Complete"))
 		b.WriteString("\n\n")
@@ -303,7 +474,17 @@ func (m model) renderComplete() string {
 		descStyle := lipgloss.NewStyle().Foreground(FgMuted)
 
 		b.WriteString(fmt.Sprintf("  %s  %s\n", cmdStyle.Render("opencode"), descStyle.Render("Start OpenCode")))
-		b.WriteString(fmt.Sprintf("  %s  %s\n\n", cmdStyle.Render("cursor-acp/auto"), descStyle.Render("Use as model name")))
+		if m.defaultModel != "" || m.smallModel != "" {
+			if m.defaultModel != "" {
+				b.WriteString(fmt.Sprintf("  %s  %s\n", cmdStyle.Render(m.defaultModel), descStyle.Render("Default model")))
+			}
+			if m.smallModel != "" {
+				b.WriteString(fmt.Sprintf("  %s  %s\n", cmdStyle.Render(m.smallModel), descStyle.Render("Small model")))
+			}
+			b.WriteString("\n")
+		} else {
+			b.WriteString(fmt.Sprintf("  %s  %s\n\n", cmdStyle.Render("cursor-acp/auto"), descStyle.Render("Use as model name")))
+		}
 
 		if !cursorAgentLoggedIn() {
 			b.WriteString(lipgloss.NewStyle().Foreground(WarningColor).Render("⚠ Remember to run: cursor-agent login"))
@@ -311,8 +492,19 @@ func (m model) renderComplete() string {
 		}
 
 		pathStyle := lipgloss.NewStyle().Foreground(FgMuted).Italic(true)
-		b.WriteString(fmt.Sprintf("Plugin:  %s\n", pathStyle.Render(m.pluginDir+"/cursor-acp.js")))
+		plugin := pluginFilePath(m.pluginDir)
+		if mode := detectLinkMode(plugin); mode != "" {
+			plugin += " (" + mode + ")"
+		}
+		b.WriteString(fmt.Sprintf("Plugin:  %s\n", pathStyle.Render(plugin)))
 		b.WriteString(fmt.Sprintf("Config:  %s\n", pathStyle.Render(m.configPath)))
+		if m.sourceCommit != "" {
+			source := m.projectDir + " @ " + shortCommit(m.sourceCommit)
+			if m.sourceRef != "" {
+				source += " (" + m.sourceRef + ")"
+			}
+			b.WriteString(fmt.Sprintf("Source:  %s\n", pathStyle.Render(source)))
+		}
 	}
 
 	b.WriteString("\n")
@@ -320,3 +512,22 @@ func (m model) renderComplete() string {
 
 	return b.String()
 }
+
+// renderErrorMessage renders a task error; continuation lines (e.g. one per
+// config validation issue) are indented under the first.
+func renderErrorMessage(message string) string {
+	lines := strings.Split(message, "\n")
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("  └─ Error: %s\n", lines[0]))
+	for _, line := range lines[1:] {
+		b.WriteString(fmt.Sprintf("       %s\n", line))
+	}
+	return lipgloss.NewStyle().Foreground(ErrorColor).Render(b.String())
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,438 @@
+// pkg/opencodeconfig/config.go
+
+// Package opencodeconfig reads and writes OpenCode's opencode.json.
+//
+// Only the parts of the schema the cursor-acp tooling touches are typed; every
+// other field, at any level, is kept as raw JSON and written back unchanged.
+// So is a typed field holding something its Go type can't represent (a
+// number where a string is expected, an object that isn't one), and an
+// explicit empty string: parsing never fails on the shape of a valid JSON
+// document.
+package opencodeconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// ProviderID is the provider key (and legacy plugin entry) for cursor-acp.
+	ProviderID = "cursor-acp"
+	// DefaultProviderName is the display name written for a new provider.
+	DefaultProviderName = "Cursor Agent (ACP stdin)"
+	// DefaultBaseURL is where the plugin's OpenAI-compatible proxy listens.
+	DefaultBaseURL = "http://127.0.0.1:32124/v1"
+	// NPMPackage is the published plugin package.
+	NPMPackage = "@rama_nigg/open-cursor"
+	// LegacyAuthPlugin is the pre-ACP plugin that must not be loaded alongside.
+	LegacyAuthPlugin = "cursor-acp-auth"
+)
+
+// Config is the root of opencode.json.
+type Config struct {
+	Schema     string
+	Model      string
+	SmallModel string
+	Plugin     []PluginEntry
+	Provider   map[string]*Provider
+	Agent      map[string]*Agent
+	Mode       map[string]*Agent
+
+	extra fields
+}
+
+// Provider is an entry of the "provider" map.
+type Provider struct {
+	NPM     string
+	Name    string
+	Options *Options
+	Models  map[string]*Model
+
+	extra fields
+	raw   json.RawMessage // set when the entry isn't an object
+}
+
+// Options is a provider's "options" block.
+type Options struct {
+	BaseURL string
+
+	extra fields
+	raw   json.RawMessage
+}
+
+// Model is an entry of a provider's "models" map.
+type Model struct {
+	Name string
+
+	extra fields
+	raw   json.RawMessage
+}
+
+// Agent is an entry of the "agent" or "mode" map; only the model is typed.
+type Agent struct {
+	Model string
+
+	extra fields
+	raw   json.RawMessage
+}
+
+// fields holds a JSON object while it is decoded: known keys are taken out
+// one by one and whatever remains is preserved verbatim.
+type fields map[string]json.RawMessage
+
+func decodeFields(data []byte) (fields, error) {
+	var f fields
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	if f == nil {
+		f = fields{}
+	}
+	return f, nil
+}
+
+// take decodes key into v and removes it from f. A value v can't hold, or an
+// explicit "", stays in f (and v stays zero) so it is written back as found.
+func (f fields) take(key string, v interface{}) {
+	raw, ok := f[key]
+	if !ok {
+		return
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		rv := reflect.ValueOf(v).Elem()
+		rv.Set(reflect.Zero(rv.Type()))
+		return
+	}
+	if s, ok := v.(*string); ok && *s == "" {
+		return
+	}
+	delete(f, key)
+}
+
+// object starts an output object from the preserved fields.
+func (f fields) object() map[string]interface{} {
+	out := make(map[string]interface{}, len(f)+4)
+	for k, v := range f {
+		out[k] = v
+	}
+	return out
+}
+
+func setString(out map[string]interface{}, key, value string) {
+	if value != "" {
+		out[key] = value
+	}
+}
+
+func (c *Config) UnmarshalJSON(data []byte) error {
+	f, err := decodeFields(data)
+	if err != nil {
+		return err
+	}
+	*c = Config{}
+	f.take("$schema", &c.Schema)
+	f.take("model", &c.Model)
+	f.take("small_model", &c.SmallModel)
+	f.take("plugin", &c.Plugin)
+	f.take("provider", &c.Provider)
+	f.take("agent", &c.Agent)
+	f.take("mode", &c.Mode)
+	c.extra = f
+	return nil
+}
+
+func (c Config) MarshalJSON() ([]byte, error) {
+	out := c.extra.object()
+	setString(out, "$schema", c.Schema)
+	setString(out, "model", c.Model)
+	setString(out, "small_model", c.SmallModel)
+	if c.Plugin != nil {
+		out["plugin"] = c.Plugin
+	}
+	if c.Provider != nil {
+		out["provider"] = c.Provider
+	}
+	if c.Agent != nil {
+		out["agent"] = c.Agent
+	}
+	if c.Mode != nil {
+		out["mode"] = c.Mode
+	}
+	return json.Marshal(out)
+}
+
+func (p *Provider) UnmarshalJSON(data []byte) error {
+	f, err := decodeFields(data)
+	if err != nil {
+		*p = Provider{raw: append(json.RawMessage(nil), data...)}
+		return nil
+	}
+	*p = Provider{}
+	f.take("npm", &p.NPM)
+	f.take("name", &p.Name)
+	f.take("options", &p.Options)
+	f.take("models", &p.Models)
+	p.extra = f
+	return nil
+}
+
+func (p Provider) MarshalJSON() ([]byte, error) {
+	if p.raw != nil {
+		return p.raw, nil
+	}
+	out := p.extra.object()
+	setString(out, "npm", p.NPM)
+	setString(out, "name", p.Name)
+	if p.Options != nil {
+		out["options"] = p.Options
+	}
+	if p.Models != nil {
+		out["models"] = p.Models
+	}
+	return json.Marshal(out)
+}
+
+func (o *Options) UnmarshalJSON(data []byte) error {
+	f, err := decodeFields(data)
+	if err != nil {
+		*o = Options{raw: append(json.RawMessage(nil), data...)}
+		return nil
+	}
+	*o = Options{}
+	f.take("baseURL", &o.BaseURL)
+	o.extra = f
+	return nil
+}
+
+func (o Options) MarshalJSON() ([]byte, error) {
+	if o.raw != nil {
+		return o.raw, nil
+	}
+	out := o.extra.object()
+	setString(out, "baseURL", o.BaseURL)
+	return json.Marshal(out)
+}
+
+func (m *Model) UnmarshalJSON(data []byte) error {
+	f, err := decodeFields(data)
+	if err != nil {
+		*m = Model{raw: append(json.RawMessage(nil), data...)}
+		return nil
+	}
+	*m = Model{}
+	f.take("name", &m.Name)
+	m.extra = f
+	return nil
+}
+
+func (m Model) MarshalJSON() ([]byte, error) {
+	if m.raw != nil {
+		return m.raw, nil
+	}
+	out := m.extra.object()
+	setString(out, "name", m.Name)
+	return json.Marshal(out)
+}
+
+func (a *Agent) UnmarshalJSON(data []byte) error {
+	f, err := decodeFields(data)
+	if err != nil {
+		*a = Agent{raw: append(json.RawMessage(nil), data...)}
+		return nil
+	}
+	*a = Agent{}
+	f.take("model", &a.Model)
+	a.extra = f
+	return nil
+}
+
+func (a Agent) MarshalJSON() ([]byte, error) {
+	if a.raw != nil {
+		return a.raw, nil
+	}
+	out := a.extra.object()
+	setString(out, "model", a.Model)
+	return json.Marshal(out)
+}
+
+// Parse decodes opencode.json content.
+func Parse(data []byte) (*Config, error) {
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Load reads and decodes the config at path. A missing file is reported as an
+// error satisfying os.IsNotExist.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// LoadOrEmpty is Load, but returns an empty config when the file is missing.
+func LoadOrEmpty(path string) (*Config, error) {
+	c, err := Load(path)
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+	return c, err
+}
+
+// Marshal encodes the config with two-space indentation.
+func (c *Config) Marshal() ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
+
+// Clone returns a deep copy of the config.
+func (c *Config) Clone() *Config {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return &Config{}
+	}
+	clone, err := Parse(data)
+	if err != nil {
+		return &Config{}
+	}
+	return clone
+}
+
+// Save writes the config to path, creating the parent directory if needed.
+func (c *Config) Save(path string) error {
+	data, err := c.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to serialize config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// EnsureProvider returns the provider with the given id, adding an empty one
+// if it doesn't exist. A provider that isn't an object is the user's to fix,
+// so it is an error rather than replaced.
+func (c *Config) EnsureProvider(id string) (*Provider, error) {
+	if c.Provider == nil {
+		c.Provider = make(map[string]*Provider)
+	}
+	p := c.Provider[id]
+	if p == nil {
+		p = &Provider{}
+		c.Provider[id] = p
+	}
+	if p.raw != nil {
+		return nil, fmt.Errorf("%s provider has invalid type (expected object, got %s)", id, jsonKind(p.raw))
+	}
+	return p, nil
+}
+
+// jsonKind names the JSON type of a value for error messages.
+func jsonKind(raw json.RawMessage) string {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "invalid JSON"
+	}
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// CursorACP returns the cursor-acp provider, or nil when it isn't configured.
+func (c *Config) CursorACP() *Provider {
+	return c.Provider[ProviderID]
+}
+
+// EnsureCursorACP returns the cursor-acp provider, creating it if needed and
+// filling in the name and options.baseURL without overriding user values. Like
+// EnsureProvider, it fails rather than replace a provider or options value
+// that isn't an object.
+func (c *Config) EnsureCursorACP() (*Provider, error) {
+	p, err := c.EnsureProvider(ProviderID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Options != nil && p.Options.raw != nil {
+		return nil, fmt.Errorf("%s provider options has invalid type (expected object, got %s)", ProviderID, jsonKind(p.Options.raw))
+	}
+	if p.Name == "" {
+		p.Name = DefaultProviderName
+	}
+	if p.Options == nil {
+		p.Options = &Options{}
+	}
+	if p.Options.BaseURL == "" {
+		p.Options.BaseURL = DefaultBaseURL
+	}
+	return p, nil
+}
+
+// RemoveProvider deletes a provider and reports whether it was present.
+func (c *Config) RemoveProvider(id string) bool {
+	if _, ok := c.Provider[id]; !ok {
+		return false
+	}
+	delete(c.Provider, id)
+	return true
+}
+
+// SetModels replaces the provider's models with id → display name.
+func (p *Provider) SetModels(models map[string]string) {
+	p.Models = make(map[string]*Model, len(models))
+	for id, name := range models {
+		p.Models[id] = &Model{Name: name}
+	}
+}
+
+// ModelIDs returns the provider's model ids, sorted.
+func (p *Provider) ModelIDs() []string {
+	if p == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(p.Models))
+	for id := range p.Models {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// HasModel reports whether the provider declares the model id.
+func (p *Provider) HasModel(id string) bool {
+	if p == nil {
+		return false
+	}
+	_, ok := p.Models[id]
+	return ok
+}
+
+// SplitModelRef splits "provider/model" into its parts.
+func SplitModelRef(ref string) (provider, model string, ok bool) {
+	return strings.Cut(ref, "/")
+}
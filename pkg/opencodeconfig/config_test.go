@@ -0,0 +1,197 @@
+// pkg/opencodeconfig/config_test.go
+package opencodeconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRoundTripPreservesUnknownFields(t *testing.T) {
+	input := `{
+  "$schema": "https://opencode.ai/config.json",
+  "theme": "tokyonight",
+  "model": "cursor-acp/auto",
+  "plugin": ["cursor-acp", {"path": "./local.js"}],
+  "provider": {
+    "cursor-acp": {
+      "name": "Mine",
+      "options": {"baseURL": "http://127.0.0.1:9/v1", "timeout": 30},
+      "models": {"auto": {"name": "Auto", "limit": {"context": 1000}}}
+    },
+    "anthropic": {"options": {"apiKey": "x"}}
+  },
+  "agent": {"build": {"model": "cursor-acp/auto", "temperature": 0.2}}
+}`
+
+	c, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.CursorACP().Options.BaseURL; got != "http://127.0.0.1:9/v1" {
+		t.Errorf("baseURL = %q", got)
+	}
+	if !c.HasPlugin(PluginEntry.IsCursorACP) {
+		t.Error("cursor-acp plugin entry not recognised")
+	}
+
+	out, err := c.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want, got interface{}
+	if err := json.Unmarshal([]byte(input), &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip changed config:\n%s", out)
+	}
+}
+
+func TestEnsureCursorACPKeepsUserValues(t *testing.T) {
+	c, err := Parse([]byte(`{"provider": {"cursor-acp": {"name": "Mine"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := c.EnsureCursorACP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "Mine" {
+		t.Errorf("name = %q, want Mine", p.Name)
+	}
+	if p.Options.BaseURL != DefaultBaseURL {
+		t.Errorf("baseURL = %q, want %q", p.Options.BaseURL, DefaultBaseURL)
+	}
+
+	empty := &Config{}
+	if p, err := empty.EnsureCursorACP(); err != nil || p.Name != DefaultProviderName {
+		t.Errorf("name = %q (%v), want %q", p.Name, err, DefaultProviderName)
+	}
+}
+
+func TestEnsureCursorACPRejectsNonObjects(t *testing.T) {
+	for _, input := range []string{
+		`{"provider": {"cursor-acp": "not an object"}}`,
+		`{"provider": {"cursor-acp": {"options": ["not", "an", "object"]}}}`,
+	} {
+		c, err := Parse([]byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.EnsureCursorACP(); err == nil || !strings.Contains(err.Error(), "invalid type") {
+			t.Errorf("%s: err = %v, want an invalid type error", input, err)
+		}
+		out, _ := c.Marshal()
+		var want, got interface{}
+		json.Unmarshal([]byte(input), &want)
+		json.Unmarshal(out, &got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: user value replaced:\n%s", input, out)
+		}
+	}
+}
+
+func TestPluginEntries(t *testing.T) {
+	c := &Config{}
+	if !c.AddPlugin(NPMPackage + "@latest") {
+		t.Fatal("AddPlugin reported no change")
+	}
+	if c.AddPlugin(NPMPackage + "@latest") {
+		t.Error("AddPlugin added a duplicate")
+	}
+	c.Plugin = append(c.Plugin, Plugin(LegacyAuthPlugin+"@1.0.0"))
+	if n := c.RemovePlugins(PluginEntry.IsLegacyAuth); n != 1 {
+		t.Errorf("removed %d legacy entries, want 1", n)
+	}
+
+	name, version := Plugin("@scope/pkg@1.2.3").Package()
+	if name != "@scope/pkg" || version != "1.2.3" {
+		t.Errorf("Package() = %q, %q", name, version)
+	}
+}
+
+func TestSetCursorACPPlugin(t *testing.T) {
+	c := &Config{Plugin: []PluginEntry{
+		Plugin("other-plugin"),
+		Plugin(ProviderID),
+		Plugin(NPMPackage + "@latest"),
+		Plugin(NPMPackage + "@2.3.9"),
+		Plugin(LegacyAuthPlugin),
+	}}
+	if n := len(c.CursorACPVariants()); n != 4 {
+		t.Fatalf("variants = %d, want 4", n)
+	}
+
+	removed := c.SetCursorACPPlugin(NPMPackage + "@2.3.9")
+	want := []string{ProviderID, NPMPackage + "@latest", LegacyAuthPlugin}
+	if !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed = %v, want %v", removed, want)
+	}
+	specs := make([]string, len(c.Plugin))
+	for i, p := range c.Plugin {
+		specs[i] = p.Spec
+	}
+	if !reflect.DeepEqual(specs, []string{"other-plugin", NPMPackage + "@2.3.9"}) {
+		t.Errorf("plugin = %v", specs)
+	}
+}
+
+func TestParseKeepsMistypedAndEmptyValues(t *testing.T) {
+	input := `{
+  "model": 42,
+  "small_model": "",
+  "plugin": "cursor-acp",
+  "provider": {
+    "cursor-acp": {"name": "", "options": {"baseURL": ["not", "a", "string"]}, "models": {"auto": true}},
+    "broken": "not an object"
+  },
+  "agent": {"build": null, "plan": {"model": {"id": "x"}}}
+}`
+
+	c, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if c.Model != "" || c.Plugin != nil || !c.CursorACP().HasModel("auto") {
+		t.Errorf("typed view = %+v", c)
+	}
+
+	out, err := c.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want, got interface{}
+	if err := json.Unmarshal([]byte(input), &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip changed config:\n%s", out)
+	}
+
+	// Setting a typed field replaces the value it couldn't hold, but a
+	// provider that isn't an object is left for the user to fix.
+	c.Model = "cursor-acp/auto"
+	if _, err := c.EnsureCursorACP(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.EnsureProvider("broken"); err == nil {
+		t.Error("EnsureProvider replaced a non-object provider")
+	}
+	out, _ = c.Marshal()
+	fixed, err := Parse(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixed.Model != "cursor-acp/auto" || fixed.CursorACP().Options.BaseURL != DefaultBaseURL || !strings.Contains(string(out), `"not an object"`) {
+		t.Errorf("after edits:\n%s", out)
+	}
+}
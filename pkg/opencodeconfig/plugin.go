@@ -0,0 +1,183 @@
+// pkg/opencodeconfig/plugin.go
+package opencodeconfig
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// PluginEntry is an element of the "plugin" array. OpenCode plugins are
+// normally package specs or names; anything else is preserved as raw JSON.
+type PluginEntry struct {
+	Spec string
+
+	raw json.RawMessage
+}
+
+// Plugin returns a plugin entry for the given spec.
+func Plugin(spec string) PluginEntry {
+	return PluginEntry{Spec: spec}
+}
+
+func (e *PluginEntry) UnmarshalJSON(data []byte) error {
+	var spec string
+	if err := json.Unmarshal(data, &spec); err == nil {
+		*e = PluginEntry{Spec: spec}
+		return nil
+	}
+	*e = PluginEntry{raw: append(json.RawMessage(nil), data...)}
+	return nil
+}
+
+func (e PluginEntry) MarshalJSON() ([]byte, error) {
+	if e.raw != nil {
+		return e.raw, nil
+	}
+	return json.Marshal(e.Spec)
+}
+
+// IsString reports whether the entry is a plain string spec.
+func (e PluginEntry) IsString() bool {
+	return e.raw == nil
+}
+
+// Package splits an npm spec into package name and version/tag, handling
+// scoped names ("@scope/name@1.2.3").
+func (e PluginEntry) Package() (name, version string) {
+	spec := e.Spec
+	offset := 0
+	if strings.HasPrefix(spec, "@") {
+		offset = 1
+	}
+	if i := strings.Index(spec[offset:], "@"); i >= 0 {
+		return spec[:offset+i], spec[offset+i+1:]
+	}
+	return spec, ""
+}
+
+// PluginKind classifies a plugin entry relative to cursor-acp.
+type PluginKind int
+
+const (
+	// PluginOther is an unrelated plugin.
+	PluginOther PluginKind = iota
+	// PluginLocal is the bare "cursor-acp" entry, loaded through the symlink
+	// in OpenCode's plugin directory.
+	PluginLocal
+	// PluginNPM is the published package at any version or tag.
+	PluginNPM
+	// PluginLegacy is the retired cursor-acp-auth plugin.
+	PluginLegacy
+)
+
+// Kind classifies the entry.
+func (e PluginEntry) Kind() PluginKind {
+	switch {
+	case !e.IsString():
+		return PluginOther
+	case e.Spec == ProviderID:
+		return PluginLocal
+	case e.IsLegacyAuth():
+		return PluginLegacy
+	}
+	if name, _ := e.Package(); name == NPMPackage {
+		return PluginNPM
+	}
+	return PluginOther
+}
+
+// IsCursorACP reports whether the entry loads this plugin, either as the
+// local "cursor-acp" symlink or the npm package at any version.
+func (e PluginEntry) IsCursorACP() bool {
+	if !e.IsString() {
+		return false
+	}
+	if e.Spec == ProviderID {
+		return true
+	}
+	name, _ := e.Package()
+	return name == NPMPackage
+}
+
+// IsLegacyAuth reports whether the entry is the retired cursor-acp-auth plugin.
+func (e PluginEntry) IsLegacyAuth() bool {
+	return e.IsString() && strings.HasPrefix(e.Spec, LegacyAuthPlugin)
+}
+
+// HasPlugin reports whether any entry satisfies match.
+func (c *Config) HasPlugin(match func(PluginEntry) bool) bool {
+	for _, p := range c.Plugin {
+		if match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddPlugin appends spec unless an identical entry exists. It reports whether
+// the array changed.
+func (c *Config) AddPlugin(spec string) bool {
+	for _, p := range c.Plugin {
+		if p.IsString() && p.Spec == spec {
+			return false
+		}
+	}
+	c.Plugin = append(c.Plugin, Plugin(spec))
+	return true
+}
+
+// RemovePlugins drops every entry satisfying match and returns how many were
+// removed.
+func (c *Config) RemovePlugins(match func(PluginEntry) bool) int {
+	if c.Plugin == nil {
+		return 0
+	}
+	kept := make([]PluginEntry, 0, len(c.Plugin))
+	for _, p := range c.Plugin {
+		if !match(p) {
+			kept = append(kept, p)
+		}
+	}
+	removed := len(c.Plugin) - len(kept)
+	c.Plugin = kept
+	return removed
+}
+
+// CursorACPVariants returns the indices of every entry that loads some form of
+// this plugin: the local entry, the npm package and the legacy auth plugin.
+func (c *Config) CursorACPVariants() []int {
+	var indices []int
+	for i, p := range c.Plugin {
+		if p.Kind() != PluginOther {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// SetCursorACPPlugin makes spec the only cursor-acp entry. It takes the place
+// of the first existing variant (or is appended) and every other variant is
+// dropped. It returns the specs that were removed.
+func (c *Config) SetCursorACPPlugin(spec string) []string {
+	var removed []string
+	kept := make([]PluginEntry, 0, len(c.Plugin)+1)
+	placed := false
+	for _, p := range c.Plugin {
+		if p.Kind() == PluginOther {
+			kept = append(kept, p)
+			continue
+		}
+		if !placed {
+			kept = append(kept, Plugin(spec))
+			placed = true
+		}
+		if p.Spec != spec {
+			removed = append(removed, p.Spec)
+		}
+	}
+	if !placed {
+		kept = append(kept, Plugin(spec))
+	}
+	c.Plugin = kept
+	return removed
+}
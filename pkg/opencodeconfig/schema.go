@@ -0,0 +1,473 @@
+// pkg/opencodeconfig/schema.go
+package opencodeconfig
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SchemaURL is the $schema value OpenCode writes into new configs.
+const SchemaURL = "https://opencode.ai/config.json"
+
+// embeddedSchema is the copy of OpenCode's config schema that remote $schema
+// URLs resolve to; validation never fetches them. Refresh it from SchemaURL
+// with `go generate ./pkg/opencodeconfig` and commit the file unedited, so
+// configs are checked against exactly what OpenCode publishes.
+//
+//go:generate curl -fsSL -o schema/config.json https://opencode.ai/config.json
+//go:embed schema/config.json
+var embeddedSchema []byte
+
+// schema is the subset of JSON Schema (draft-07 and 2020-12) that OpenCode's
+// generated config schema uses. Annotations such as description, default and
+// format are ignored.
+type schema struct {
+	ID                   string             `json:"$id"`
+	Ref                  string             `json:"$ref"`
+	Type                 schemaTypes        `json:"type"`
+	Const                json.RawMessage    `json:"const"`
+	Enum                 []interface{}      `json:"enum"`
+	Properties           map[string]*schema `json:"properties"`
+	PatternProperties    map[string]*schema `json:"patternProperties"`
+	AdditionalProperties *schema            `json:"additionalProperties"`
+	PropertyNames        *schema            `json:"propertyNames"`
+	Required             []string           `json:"required"`
+	MinProperties        *int               `json:"minProperties"`
+	MaxProperties        *int               `json:"maxProperties"`
+	Items                *schemaItems       `json:"items"`
+	PrefixItems          []*schema          `json:"prefixItems"`
+	MinItems             *int               `json:"minItems"`
+	MaxItems             *int               `json:"maxItems"`
+	UniqueItems          bool               `json:"uniqueItems"`
+	Pattern              string             `json:"pattern"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum"`
+	MultipleOf           *float64           `json:"multipleOf"`
+	AllOf                []*schema          `json:"allOf"`
+	AnyOf                []*schema          `json:"anyOf"`
+	OneOf                []*schema          `json:"oneOf"`
+	Not                  *schema            `json:"not"`
+
+	// never is set for the boolean schema false, which no value matches.
+	never bool
+}
+
+// UnmarshalJSON accepts the boolean schemas true (anything) and false
+// (nothing) as well as schema objects.
+func (s *schema) UnmarshalJSON(data []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(data, &allowed); err == nil {
+		s.never = !allowed
+		return nil
+	}
+	type plain schema
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// schemaTypes accepts "type" as either a string or an array of strings.
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// schemaItems is "items" as a single schema for every element or, in
+// draft-07, an array of schemas for the leading elements.
+type schemaItems struct {
+	all   *schema
+	tuple []*schema
+}
+
+func (i *schemaItems) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &i.tuple); err == nil {
+		return nil
+	}
+	return json.Unmarshal(data, &i.all)
+}
+
+// schemaDoc is a parsed schema document. $ref pointers into it are resolved
+// on first use, so refs can reach any subschema, not only definitions.
+type schemaDoc struct {
+	root     *schema
+	raw      interface{}
+	refs     map[string]*schema
+	patterns map[string]*regexp.Regexp
+}
+
+func parseSchema(data []byte) (*schemaDoc, error) {
+	doc := &schemaDoc{
+		refs:     make(map[string]*schema),
+		patterns: make(map[string]*regexp.Regexp),
+	}
+	if err := json.Unmarshal(data, &doc.root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := json.Unmarshal(data, &doc.raw); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return doc, nil
+}
+
+// remoteSchema reports whether a $schema value is a URL rather than a path.
+func remoteSchema(ref string) bool {
+	return strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://")
+}
+
+// loadSchema resolves the config's $schema. Remote URLs use the embedded
+// copy; a local path (absolute, relative to the config file, or file://) is
+// read from disk.
+func loadSchema(ref, configDir string) (*schemaDoc, error) {
+	if ref == "" || remoteSchema(ref) {
+		return parseSchema(embeddedSchema)
+	}
+
+	path := ref
+	if u, err := url.Parse(ref); err == nil && u.Scheme == "file" {
+		path = u.Path
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(configDir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read $schema %s: %w", ref, err)
+	}
+	return parseSchema(data)
+}
+
+// resolve returns the subschema a $ref points at, or nil when the ref leaves
+// the document or doesn't resolve.
+func (d *schemaDoc) resolve(ref string) *schema {
+	s, ok := d.refs[ref]
+	if !ok {
+		s = d.lookup(ref)
+		d.refs[ref] = s
+	}
+	return s
+}
+
+func (d *schemaDoc) lookup(ref string) *schema {
+	base, fragment, _ := strings.Cut(ref, "#")
+	if base != "" && base != d.root.ID {
+		return nil
+	}
+	if fragment == "" {
+		return d.root
+	}
+	if !strings.HasPrefix(fragment, "/") {
+		return nil
+	}
+	node := d.raw
+	for _, token := range strings.Split(fragment[1:], "/") {
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch n := node.(type) {
+		case map[string]interface{}:
+			node = n[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil
+			}
+			node = n[i]
+		default:
+			return nil
+		}
+	}
+	if node == nil {
+		return nil
+	}
+	data, err := json.Marshal(node)
+	if err != nil {
+		return nil
+	}
+	var s *schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil
+	}
+	return s
+}
+
+// compile returns the regexp for a schema pattern, or nil when Go's regexp
+// syntax can't express it.
+func (d *schemaDoc) compile(pattern string) *regexp.Regexp {
+	re, ok := d.patterns[pattern]
+	if !ok {
+		re, _ = regexp.Compile(pattern)
+		d.patterns[pattern] = re
+	}
+	return re
+}
+
+// validator walks a decoded document against a schema, collecting issues.
+type validator struct {
+	doc    *schemaDoc
+	issues []Issue
+}
+
+func (v *validator) fail(pointer, format string, args ...interface{}) {
+	v.issues = append(v.issues, Issue{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(s *schema, value interface{}, pointer string) {
+	v.apply(s, value, pointer, 0)
+}
+
+// apply checks value against every keyword of s. depth counts the $refs and
+// combinators applied to the same value, so a schema that refers back to
+// itself can't recurse forever.
+func (v *validator) apply(s *schema, value interface{}, pointer string, depth int) {
+	if s == nil || depth > 32 {
+		return
+	}
+	if s.never {
+		v.fail(pointer, "is not allowed")
+		return
+	}
+	if s.Ref != "" {
+		v.apply(v.doc.resolve(s.Ref), value, pointer, depth+1)
+	}
+
+	for _, sub := range s.AllOf {
+		v.apply(sub, value, pointer, depth+1)
+	}
+	if len(s.AnyOf) > 0 && v.countMatches(s.AnyOf, value, pointer, depth) == 0 {
+		v.fail(pointer, "does not match any allowed form")
+	}
+	if len(s.OneOf) > 0 {
+		if n := v.countMatches(s.OneOf, value, pointer, depth); n != 1 {
+			v.fail(pointer, "matches %d of the allowed forms, want exactly 1", n)
+		}
+	}
+	if s.Not != nil && v.countMatches([]*schema{s.Not}, value, pointer, depth) == 1 {
+		v.fail(pointer, "matches a form that is not allowed")
+	}
+
+	if len(s.Type) > 0 && !typeMatches(s.Type, value) {
+		v.fail(pointer, "expected %s, got %s", strings.Join(s.Type, " or "), jsonType(value))
+		return
+	}
+
+	if s.Const != nil {
+		var want interface{}
+		if err := json.Unmarshal(s.Const, &want); err == nil && !reflect.DeepEqual(want, value) {
+			v.fail(pointer, "must be %s", s.Const)
+		}
+	}
+	if len(s.Enum) > 0 && !enumContains(s.Enum, value) {
+		v.fail(pointer, "must be one of %s", formatEnum(s.Enum))
+	}
+
+	switch value := value.(type) {
+	case string:
+		v.validateString(s, value, pointer)
+	case float64:
+		v.validateNumber(s, value, pointer)
+	case []interface{}:
+		v.validateArray(s, value, pointer)
+	case map[string]interface{}:
+		v.validateObject(s, value, pointer)
+	}
+}
+
+func (v *validator) validateString(s *schema, value, pointer string) {
+	length := utf8.RuneCountInString(value)
+	if s.MinLength != nil && length < *s.MinLength {
+		v.fail(pointer, "must be at least %d characters", *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		v.fail(pointer, "must be at most %d characters", *s.MaxLength)
+	}
+	if s.Pattern != "" {
+		if re := v.doc.compile(s.Pattern); re != nil && !re.MatchString(value) {
+			v.fail(pointer, "%q does not match %s", value, s.Pattern)
+		}
+	}
+}
+
+func (v *validator) validateNumber(s *schema, value float64, pointer string) {
+	if s.Minimum != nil && value < *s.Minimum {
+		v.fail(pointer, "must be >= %v", *s.Minimum)
+	}
+	if s.Maximum != nil && value > *s.Maximum {
+		v.fail(pointer, "must be <= %v", *s.Maximum)
+	}
+	if s.ExclusiveMinimum != nil && value <= *s.ExclusiveMinimum {
+		v.fail(pointer, "must be > %v", *s.ExclusiveMinimum)
+	}
+	if s.ExclusiveMaximum != nil && value >= *s.ExclusiveMaximum {
+		v.fail(pointer, "must be < %v", *s.ExclusiveMaximum)
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		if q := value / *s.MultipleOf; q != math.Trunc(q) {
+			v.fail(pointer, "must be a multiple of %v", *s.MultipleOf)
+		}
+	}
+}
+
+func (v *validator) validateArray(s *schema, value []interface{}, pointer string) {
+	if s.MinItems != nil && len(value) < *s.MinItems {
+		v.fail(pointer, "must have at least %d items", *s.MinItems)
+	}
+	if s.MaxItems != nil && len(value) > *s.MaxItems {
+		v.fail(pointer, "must have at most %d items", *s.MaxItems)
+	}
+	if s.UniqueItems {
+		for i := range value {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(value[i], value[j]) {
+					v.fail(fmt.Sprintf("%s/%d", pointer, i), "duplicates item %d", j)
+					break
+				}
+			}
+		}
+	}
+
+	tuple := s.PrefixItems
+	var rest *schema
+	if s.Items != nil {
+		if s.Items.tuple != nil {
+			tuple = s.Items.tuple
+		} else {
+			rest = s.Items.all
+		}
+	}
+	for i, item := range value {
+		child := fmt.Sprintf("%s/%d", pointer, i)
+		if i < len(tuple) {
+			v.validate(tuple[i], item, child)
+		} else if rest != nil {
+			v.validate(rest, item, child)
+		}
+	}
+}
+
+func (v *validator) validateObject(s *schema, value map[string]interface{}, pointer string) {
+	for _, key := range s.Required {
+		if _, ok := value[key]; !ok {
+			v.fail(pointer, "missing required property %q", key)
+		}
+	}
+	if s.MinProperties != nil && len(value) < *s.MinProperties {
+		v.fail(pointer, "must have at least %d properties", *s.MinProperties)
+	}
+	if s.MaxProperties != nil && len(value) > *s.MaxProperties {
+		v.fail(pointer, "must have at most %d properties", *s.MaxProperties)
+	}
+
+	for _, key := range sortedKeys(value) {
+		child := pointer + "/" + escapePointer(key)
+		if s.PropertyNames != nil {
+			v.validate(s.PropertyNames, key, child)
+		}
+		matched := false
+		if prop, ok := s.Properties[key]; ok {
+			v.validate(prop, value[key], child)
+			matched = true
+		}
+		for _, pattern := range sortedKeys(s.PatternProperties) {
+			if re := v.doc.compile(pattern); re != nil && re.MatchString(key) {
+				v.validate(s.PatternProperties[pattern], value[key], child)
+				matched = true
+			}
+		}
+		if matched || s.AdditionalProperties == nil {
+			continue
+		}
+		if s.AdditionalProperties.never {
+			v.fail(child, "unknown property %q", key)
+			continue
+		}
+		v.validate(s.AdditionalProperties, value[key], child)
+	}
+}
+
+func (v *validator) countMatches(options []*schema, value interface{}, pointer string, depth int) int {
+	n := 0
+	for _, option := range options {
+		sub := validator{doc: v.doc}
+		sub.apply(option, value, pointer, depth+1)
+		if len(sub.issues) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func jsonType(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if value == math.Trunc(value) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func typeMatches(types []string, value interface{}) bool {
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func enumContains(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if reflect.DeepEqual(e, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []interface{}) string {
+	parts := make([]string, len(enum))
+	for i, e := range enum {
+		data, _ := json.Marshal(e)
+		parts[i] = string(data)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
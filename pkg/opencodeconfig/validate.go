@@ -0,0 +1,265 @@
+// pkg/opencodeconfig/validate.go
+package opencodeconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Issue is a single validation finding, located by JSON pointer and, when the
+// pointer resolves in the source, by 1-based line and column.
+type Issue struct {
+	Pointer string
+	Line    int
+	Column  int
+	Message string
+	// Warning issues are reported but don't make the config invalid.
+	Warning bool
+}
+
+func (i Issue) String() string {
+	pointer := i.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	if i.Line > 0 {
+		return fmt.Sprintf("%s (%d:%d): %s", pointer, i.Line, i.Column, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", pointer, i.Message)
+}
+
+// ValidationError is returned by ValidateFile when the config has at least
+// one non-warning issue. Issues holds every finding, warnings included.
+type ValidationError struct {
+	Path   string
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s is invalid:", e.Path)
+	for _, issue := range e.Issues {
+		if !issue.Warning {
+			b.WriteString("\n" + issue.String())
+		}
+	}
+	return b.String()
+}
+
+// ValidateFile reads the config at path and validates it. It returns the
+// warnings when the config is valid and a *ValidationError otherwise.
+func ValidateFile(path string) ([]Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	issues, err := Validate(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate %s: %w", path, err)
+	}
+	for _, issue := range issues {
+		if !issue.Warning {
+			return nil, &ValidationError{Path: path, Issues: issues}
+		}
+	}
+	return issues, nil
+}
+
+// Validate checks config content against the schema named by its $schema
+// (the embedded copy by default) and runs the semantic checks the schema
+// can't express, whether or not the schema found issues. configDir resolves
+// a relative $schema path. A syntax error is reported as a single issue at
+// the failing offset.
+func Validate(data []byte, configDir string) ([]Issue, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		issue := Issue{Message: err.Error()}
+		var syntax *json.SyntaxError
+		if errors.As(err, &syntax) {
+			issue.Line, issue.Column = lineColumn(data, int(syntax.Offset))
+		}
+		return []Issue{issue}, nil
+	}
+
+	ref := ""
+	if obj, ok := doc.(map[string]interface{}); ok {
+		ref, _ = obj["$schema"].(string)
+	}
+	s, err := loadSchema(ref, configDir)
+	if err != nil {
+		return nil, err
+	}
+
+	v := validator{doc: s}
+	v.validate(s.root, doc, "")
+	issues := v.issues
+	if remoteSchema(ref) && ref != SchemaURL {
+		issues = append(issues, Issue{
+			Pointer: "/$schema",
+			Message: fmt.Sprintf("%s is not fetched; validated against the embedded copy of %s instead", ref, SchemaURL),
+			Warning: true,
+		})
+	}
+
+	// Parse keeps mistyped values aside rather than failing, so the semantic
+	// checks run on whatever the schema let through.
+	c, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	issues = append(issues, c.semanticIssues()...)
+
+	offsets := pointerOffsets(data)
+	for i := range issues {
+		if offset, ok := offsets[issues[i].Pointer]; ok {
+			issues[i].Line, issues[i].Column = lineColumn(data, offset)
+		}
+	}
+	return issues, nil
+}
+
+// semanticIssues covers what the schema can't: provider base URLs must be
+// absolute http(s) URLs, a plugin package should appear only once, and model
+// references should name a model the provider declares. Only the first is an
+// error; the installer may leave duplicates in place when the user declines
+// to normalize them.
+func (c *Config) semanticIssues() []Issue {
+	var issues []Issue
+
+	for _, id := range sortedKeys(c.Provider) {
+		p := c.Provider[id]
+		if p == nil || p.Options == nil || p.Options.BaseURL == "" {
+			continue
+		}
+		u, err := url.Parse(p.Options.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			issues = append(issues, Issue{
+				Pointer: "/provider/" + escapePointer(id) + "/options/baseURL",
+				Message: fmt.Sprintf("%q is not an absolute http(s) URL", p.Options.BaseURL),
+			})
+		}
+	}
+
+	seen := make(map[string]int)
+	for i, entry := range c.Plugin {
+		if !entry.IsString() {
+			continue
+		}
+		name, _ := entry.Package()
+		if first, ok := seen[name]; ok {
+			issues = append(issues, Issue{
+				Pointer: fmt.Sprintf("/plugin/%d", i),
+				Message: fmt.Sprintf("duplicate plugin %q (also at /plugin/%d)", name, first),
+				Warning: true,
+			})
+			continue
+		}
+		seen[name] = i
+	}
+
+	checkRef := func(pointer, ref string) {
+		providerID, modelID, ok := SplitModelRef(ref)
+		if !ok {
+			return
+		}
+		p := c.Provider[providerID]
+		if p == nil || p.Models == nil || p.HasModel(modelID) {
+			return
+		}
+		issues = append(issues, Issue{
+			Pointer: pointer,
+			Message: fmt.Sprintf("model %q is not declared in provider %q", modelID, providerID),
+			Warning: true,
+		})
+	}
+	checkRef("/model", c.Model)
+	checkRef("/small_model", c.SmallModel)
+	for _, section := range []struct {
+		name    string
+		entries map[string]*Agent
+	}{{"agent", c.Agent}, {"mode", c.Mode}} {
+		for _, name := range sortedKeys(section.entries) {
+			if entry := section.entries[name]; entry != nil {
+				checkRef("/"+section.name+"/"+escapePointer(name)+"/model", entry.Model)
+			}
+		}
+	}
+
+	return issues
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// pointerOffsets maps the JSON pointer of every value in data to the byte
+// offset where that value starts.
+func pointerOffsets(data []byte) map[string]int {
+	offsets := make(map[string]int)
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	// valueStart skips the separator and whitespace between the previous
+	// token and the next value.
+	valueStart := func() int {
+		offset := int(dec.InputOffset())
+		for offset < len(data) && strings.IndexByte(" \t\r\n:,", data[offset]) >= 0 {
+			offset++
+		}
+		return offset
+	}
+
+	var walk func(pointer string) error
+	walk = func(pointer string) error {
+		offsets[pointer] = valueStart()
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		delim, ok := tok.(json.Delim)
+		if !ok {
+			return nil
+		}
+		for i := 0; dec.More(); i++ {
+			if delim == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := walk(pointer + "/" + escapePointer(key.(string))); err != nil {
+					return err
+				}
+			} else if err := walk(fmt.Sprintf("%s/%d", pointer, i)); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token()
+		return err
+	}
+
+	// A truncated document still yields offsets for everything before the
+	// error.
+	_ = walk("")
+	return offsets
+}
+
+func lineColumn(data []byte, offset int) (line, column int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = offset - (bytes.LastIndexByte(before, '\n') + 1) + 1
+	return line, column
+}
@@ -0,0 +1,169 @@
+// pkg/opencodeconfig/validate_test.go
+package opencodeconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateReportsPointersAndPositions(t *testing.T) {
+	input := `{
+  "$schema": "https://opencode.ai/config.json",
+  "share": 3,
+  "plugin": ["cursor-acp", "@rama_nigg/open-cursor@latest", "cursor-acp"],
+  "provider": {
+    "cursor-acp": {
+      "options": {"baseURL": "127.0.0.1:32124"},
+      "models": {"auto": {"name": "Auto"}}
+    }
+  }
+}`
+
+	// Schema issues don't stop the semantic checks.
+	issues, err := Validate([]byte(input), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][2]int{
+		"/share":                               {3, 12},
+		"/provider/cursor-acp/options/baseURL": {7, 30},
+		"/plugin/2":                            {4, 61},
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("issues = %v", issues)
+	}
+	for _, issue := range issues {
+		pos, ok := want[issue.Pointer]
+		if !ok || issue.Line != pos[0] || issue.Column != pos[1] {
+			t.Errorf("unexpected issue %s", issue)
+		}
+		if issue.Warning != (issue.Pointer == "/plugin/2") {
+			t.Errorf("%s: warning = %v", issue, issue.Warning)
+		}
+	}
+}
+
+func TestValidateAcceptsWhatOpenCodeLoads(t *testing.T) {
+	input := `{
+  "model": "auto",
+  "share": "manual",
+  "plugin": ["@rama_nigg/open-cursor@latest", {"name": "other", "options": {"x": 1}}],
+  "mcp": {"docs": {"type": "remote", "url": "https://example.com/mcp"}},
+  "provider": {"cursor-acp": {"options": {"timeout": false}}}
+}`
+
+	issues, err := Validate([]byte(input), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("issues = %v, want none", issues)
+	}
+}
+
+func TestValidateModelRefsAreWarnings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "opencode.json")
+	data := `{"model": "cursor-acp/gone", "provider": {"cursor-acp": {"models": {"auto": {}}}}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	warnings, err := ValidateFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || warnings[0].Pointer != "/model" || !warnings[0].Warning {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
+
+func TestValidateHonorsLocalSchema(t *testing.T) {
+	dir := t.TempDir()
+	schema := `{"type": "object", "required": ["theme"]}`
+	if err := os.WriteFile(filepath.Join(dir, "strict.json"), []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "opencode.json")
+	if err := os.WriteFile(path, []byte(`{"$schema": "./strict.json"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ValidateFile(path)
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `missing required property "theme"`) {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestValidateSchemaKeywords(t *testing.T) {
+	dir := t.TempDir()
+	schema := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$ref": "#/$defs/Config",
+  "$defs": {
+    "Config": {
+      "type": "object",
+      "properties": {
+        "$schema": {"type": "string"},
+        "share": {"anyOf": [{"const": "manual"}, {"const": "auto"}]},
+        "theme": {"allOf": [{"type": "string"}, {"maxLength": 5}]},
+        "port": {"type": "integer", "exclusiveMinimum": 0, "maximum": 65535},
+        "tags": {"type": "array", "minItems": 1, "items": {"not": {"const": ""}}},
+        "keybinds": {"type": "object", "propertyNames": {"pattern": "^[a-z_]+$"}},
+        "env": {"patternProperties": {"^X_": {"type": "string"}}, "additionalProperties": false},
+        "small_model": {"$ref": "#/$defs/Config/properties/theme"}
+      },
+      "additionalProperties": false
+    }
+  }
+}`
+	if err := os.WriteFile(filepath.Join(dir, "schema.json"), []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := `{"$schema": "./schema.json", "share": "auto", "theme": "dark", "port": 80, "tags": ["a"], "keybinds": {"leader": "x"}, "env": {"X_A": "1"}, "small_model": "m"}`
+	if issues, err := Validate([]byte(valid), dir); err != nil || len(issues) != 0 {
+		t.Fatalf("valid config: issues = %v, err = %v", issues, err)
+	}
+
+	invalid := `{"$schema": "./schema.json", "share": "always", "theme": "solarized", "port": 0, "tags": [""], "keybinds": {"Leader": "x"}, "env": {"Y": "1"}, "small_model": "too long", "extra": 1}`
+	issues, err := Validate([]byte(invalid), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]bool)
+	for _, issue := range issues {
+		got[issue.Pointer] = true
+	}
+	for _, pointer := range []string{"/share", "/theme", "/port", "/tags/0", "/keybinds/Leader", "/env/Y", "/small_model", "/extra"} {
+		if !got[pointer] {
+			t.Errorf("no issue at %s in %v", pointer, issues)
+		}
+	}
+}
+
+func TestValidateWarnsAboutOtherRemoteSchemas(t *testing.T) {
+	issues, err := Validate([]byte(`{"$schema": "https://example.com/opencode.json"}`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Pointer != "/$schema" || !issues[0].Warning {
+		t.Errorf("issues = %v, want one warning at /$schema", issues)
+	}
+}
+
+func TestValidateSyntaxErrorPosition(t *testing.T) {
+	issues, err := Validate([]byte("{\n  \"model\": \"x\",\n}"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Line != 3 {
+		t.Errorf("issues = %v, want one on line 3", issues)
+	}
+}